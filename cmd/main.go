@@ -1,16 +1,138 @@
 package main
 
 import (
+	"encoding/csv"
+	"flag"
 	"fmt"
+	"io"
+	"math/big"
 	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/prybintsev/memepool/mempool"
 )
 
+// The commands given as the first argument, without a command all the transactions are written by priority
+const (
+	// commandBlock writes the transactions of a block built from the pool
+	commandBlock = "block"
+	// commandForecast writes the projected blocks of the transactions of the pool
+	commandForecast = "forecast"
+	// commandEstimate writes the fee per gas a transaction needs to be included soon or to be among the top ones
+	commandEstimate = "estimate"
+)
+
 func main() {
-	m := mempool.NewMemPool()
+	command, args := "", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+	defaultOutputPath := "prioritized-transactions.txt"
+	var gasLimit, blocks, arrivalsPerBlock, gas, top *int
+	var leftoverPath, strategy, arrivalsPath *string
+	switch command {
+	case "":
+	case commandBlock:
+		defaultOutputPath = "block-transactions.txt"
+		leftoverPath = flag.String("leftover", "", "path of the file the transactions which are not in the block are written to")
+	case commandForecast:
+		defaultOutputPath = "forecast.txt"
+		blocks = flag.Int("blocks", 10, "number of the simulated blocks")
+		arrivalsPath = flag.String("arrivals", "", "path of the file with the transactions arriving while the blocks are built")
+		arrivalsPerBlock = flag.Int("arrivals-per-block", 100, "number of the arriving transactions before every block")
+	case commandEstimate:
+		defaultOutputPath = "fee-estimate.txt"
+		gas = flag.Int("gas", 21000, "gas of the transaction the fee is estimated for")
+		blocks = flag.Int("blocks", 1, "number of the blocks the transaction should be included within, 0 disables the target")
+		top = flag.Int("top", 0, "number of the highest priority transactions the transaction should be among, 0 disables the target")
+	default:
+		fmt.Printf("Unknown command [%s]\n", command)
+		return
+	}
+	if command != "" {
+		gasLimit = flag.Int("gas-limit", 30000000, "gas limit of the blocks")
+	}
+	if command == commandBlock || command == commandForecast {
+		strategy = flag.String("strategy", "greedy", "packing strategy of the blocks, greedy by priority or optimal by the total fee")
+	}
 
-	input, err := os.Open("transactions.txt")
+	inputPath := flag.String("input", "transactions.txt", "path of the file with the transactions")
+	outputPath := flag.String("output", defaultOutputPath, "path of the file the prioritized transactions are written to")
+	lenient := flag.Bool("lenient", false, "skip the invalid lines instead of stopping on the first one")
+	maxErrors := flag.Int("max-errors", 0, "stop the lenient reading once more lines are invalid, 0 means no limit")
+	rejectedPath := flag.String("rejected", "", "path of the file the invalid lines are written to in the lenient mode")
+	atomic := flag.Bool("atomic", false, "add the transactions to the pool only if the whole input is read successfully")
+	formats := strings.Join(mempool.CodecNames(), ", ")
+	inputFormat := flag.String("input-format", "text", "format of the input, one of "+formats)
+	outputFormat := flag.String("output-format", "text", "format of the output, one of "+formats)
+	csvDelimiter := flag.String("csv-delimiter", ",", "field delimiter of the csv format")
+	csvColumns := flag.String("csv-columns", "", "comma separated columns of the csv output, e.g. Rank,TxHash,TotalFee")
+	rejectUnknown := flag.Bool("reject-unknown-fields", false, "reject the transactions with fields other than the known ones")
+	nonceOrdering := flag.Bool("nonce-ordering", false, "write the transactions of every sender in the order of their nonces")
+	replaceBump := flag.Int("replace-bump", -1, "fee bump percentage a transaction needs to replace a conflicting one, negative means the later one always replaces")
+	baseFee := flag.String("base-fee", "", "base fee per gas the transactions are prioritized with by their effective tips, none by default")
+	if err := flag.CommandLine.Parse(args); err != nil {
+		return
+	}
+	var packingStrategy mempool.PackingStrategy
+	if strategy != nil {
+		var ok bool
+		if packingStrategy, ok = packingStrategies[*strategy]; !ok {
+			fmt.Printf("Unknown packing strategy [%s]\n", *strategy)
+			return
+		}
+	}
+
+	inputCodec, err := mempool.LookupCodec(*inputFormat)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		return
+	}
+	outputCodec, err := mempool.LookupCodec(*outputFormat)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		return
+	}
+	inputCodec, err = configureCSV(inputCodec, *csvDelimiter, *csvColumns)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		return
+	}
+	if *rejectedPath != "" && !lineFormats[inputCodec.Name()] {
+		fmt.Printf("Rejected records can't be written for the input format [%s]\n", inputCodec.Name())
+		return
+	}
+	outputCodec, err = configureCSV(outputCodec, *csvDelimiter, *csvColumns)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		return
+	}
+
+	// Equal priority transactions are written in the order of arrival, so the output is reproducible
+	parseOptions := mempool.ParseOptions{RejectUnknownFields: *rejectUnknown}
+	options := []mempool.Option{
+		mempool.WithTieBreak(mempool.TieBreakArrival),
+		mempool.WithParseOptions(parseOptions),
+	}
+	if *nonceOrdering {
+		options = append(options, mempool.WithNonceOrdering())
+	}
+	if *replaceBump >= 0 {
+		options = append(options, mempool.WithReplaceByFee(*replaceBump))
+	}
+	if *baseFee != "" {
+		fee, ok := new(big.Rat).SetString(*baseFee)
+		if !ok {
+			fmt.Printf("Invalid base fee [%s]\n", *baseFee)
+			return
+		}
+		options = append(options, mempool.WithBaseFee(fee))
+	}
+	m := mempool.NewMemPool(options...)
+
+	input, err := os.Open(*inputPath)
 	if err != nil {
 		fmt.Printf("%s\n", err)
 		return
@@ -21,12 +143,35 @@ func main() {
 			fmt.Printf("%s\n", err)
 		}
 	}()
-	err = m.ReadTransactions(input)
+	report, err := m.ReadTransactionsWith(input, mempool.ReadOptions{
+		Codec:     inputCodec,
+		Lenient:   *lenient,
+		MaxErrors: *maxErrors,
+		Atomic:    *atomic,
+	})
+	if *rejectedPath != "" {
+		if rejectedErr := writeRejected(*rejectedPath, *inputPath, inputCodec, report.Errors); rejectedErr != nil {
+			fmt.Printf("%s\n", rejectedErr)
+			return
+		}
+	}
 	if err != nil {
 		fmt.Printf("%s\n", err)
 		return
 	}
-	output, err := os.Create("prioritized-transactions.txt")
+	if *lenient {
+		fmt.Printf("Accepted %d transactions, rejected %d lines\n", report.Accepted, report.Rejected)
+	}
+
+	var arrivals []mempool.Transaction
+	if arrivalsPath != nil && *arrivalsPath != "" {
+		if arrivals, err = readArrivals(*arrivalsPath, inputCodec, parseOptions); err != nil {
+			fmt.Printf("%s\n", err)
+			return
+		}
+	}
+
+	output, err := os.Create(*outputPath)
 	if err != nil {
 		fmt.Printf("%s\n", err)
 		return
@@ -38,9 +183,222 @@ func main() {
 		}
 	}()
 
-	err = m.WriteTransactions(output)
+	switch command {
+	case commandBlock:
+		err = writeBlock(m, output, outputCodec, *gasLimit, packingStrategy, *leftoverPath)
+	case commandForecast:
+		err = writeForecast(m, output, mempool.ForecastOptions{
+			GasLimit:         *gasLimit,
+			Blocks:           *blocks,
+			Strategy:         packingStrategy,
+			Arrivals:         arrivals,
+			ArrivalsPerBlock: *arrivalsPerBlock,
+		})
+	case commandEstimate:
+		err = writeEstimate(m, output, mempool.EstimateOptions{Gas: *gas, Blocks: *blocks, GasLimit: *gasLimit, Top: *top})
+	default:
+		err = m.WriteTransactionsWith(output, outputCodec)
+	}
 	if err != nil {
 		fmt.Printf("%s\n", err)
 		return
 	}
 }
+
+// packingStrategies are the packing strategies of the block and forecast commands by their names
+// lineFormats are the input formats with a record per line of text, so the rejected records can be written as they are
+var lineFormats = map[string]bool{"text": true, "jsonl": true, "csv": true}
+
+var packingStrategies = map[string]mempool.PackingStrategy{
+	"greedy":  mempool.PackGreedy,
+	"optimal": mempool.PackOptimal,
+}
+
+// writeBlock builds a block from the pool and writes its transactions, and the leftover ones if the path is set
+func writeBlock(m *mempool.MemPool, output io.Writer, codec mempool.Codec, gasLimit int, strategy mempool.PackingStrategy, leftoverPath string) error {
+	block := m.BuildBlockWith(gasLimit, strategy)
+	if err := writeTransactions(output, codec, block.Transactions); err != nil {
+		return err
+	}
+	if leftoverPath != "" {
+		leftover, err := os.Create(leftoverPath)
+		if err != nil {
+			return err
+		}
+		if err = writeTransactions(leftover, codec, block.Leftover); err != nil {
+			leftover.Close()
+			return err
+		}
+		if err = leftover.Close(); err != nil {
+			return err
+		}
+	}
+
+	fees, _ := block.Fees.Float64()
+	fmt.Printf("Built a block of %d transactions with %d gas and %s fees, %d transactions left\n",
+		len(block.Transactions), block.Gas, strconv.FormatFloat(fees, 'f', -1, 64), len(block.Leftover))
+	return nil
+}
+
+// writeForecast writes the projected block of every transaction as TxHash=<hash> Block=<number>, where the number
+// is none if the transaction is not included within the simulated blocks, and prints the number of the transactions
+// of every block
+func writeForecast(m *mempool.MemPool, output io.Writer, options mempool.ForecastOptions) error {
+	inclusions := m.Forecast(options)
+	counts := make(map[int]int)
+	for _, inclusion := range inclusions {
+		block := "none"
+		if inclusion.Block > 0 {
+			block = strconv.Itoa(inclusion.Block)
+		}
+		if _, err := fmt.Fprintf(output, "%s=%s Block=%s\n", mempool.KeyHash, inclusion.Transaction.Hash, block); err != nil {
+			return err
+		}
+		counts[inclusion.Block]++
+	}
+
+	for block := 1; block <= options.Blocks; block++ {
+		fmt.Printf("Block %d: %d transactions\n", block, counts[block])
+	}
+	fmt.Printf("Not within %d blocks: %d transactions\n", options.Blocks, counts[0])
+	return nil
+}
+
+// writeEstimate writes the estimated fees per gas as a line of Key=Value pairs, the recommended FeePerGas followed by
+// the ones of the enabled targets, the one to stay in the pool and the percentiles of the pool as P<percent>
+func writeEstimate(m *mempool.MemPool, output io.Writer, options mempool.EstimateOptions) error {
+	estimate, err := m.EstimateFee(options)
+	if err != nil {
+		return err
+	}
+	pairs := []string{mempool.KeyFee + "=" + formatFee(estimate.FeePerGas)}
+	if estimate.InclusionFeePerGas != nil {
+		pairs = append(pairs, "InclusionFeePerGas="+formatFee(estimate.InclusionFeePerGas))
+	}
+	if estimate.TopFeePerGas != nil {
+		pairs = append(pairs, "TopFeePerGas="+formatFee(estimate.TopFeePerGas))
+	}
+	pairs = append(pairs, "MinFeePerGas="+formatFee(estimate.MinFeePerGas))
+	for _, percentile := range estimate.Percentiles {
+		pairs = append(pairs, fmt.Sprintf("P%d=%s", percentile.Percent, formatFee(percentile.FeePerGas)))
+	}
+	if _, err = fmt.Fprintln(output, strings.Join(pairs, " ")); err != nil {
+		return err
+	}
+
+	fmt.Printf("Recommended fee per gas %s for a transaction with %d gas\n", formatFee(estimate.FeePerGas), options.Gas)
+	return nil
+}
+
+// formatFee formats the fee per gas as a decimal without the trailing zeros
+func formatFee(fee *big.Rat) string {
+	s := fee.FloatString(18)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// readArrivals reads the transactions arriving at the pool with the codec
+func readArrivals(path string, codec mempool.Codec, options mempool.ParseOptions) ([]mempool.Transaction, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var txs []mempool.Transaction
+	decoder := codec.NewDecoder(file, options)
+	for {
+		tx, err := decoder.Decode()
+		if err == io.EOF {
+			return txs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, tx)
+	}
+}
+
+// writeTransactions writes the transactions in their order with the codec
+func writeTransactions(writer io.Writer, codec mempool.Codec, txs []mempool.Transaction) error {
+	encoder := codec.NewEncoder(writer)
+	for _, tx := range txs {
+		if err := encoder.Encode(tx); err != nil {
+			return err
+		}
+	}
+	return encoder.Close()
+}
+
+// configureCSV sets the delimiter and the columns of the codec if it is the CSV one
+func configureCSV(codec mempool.Codec, delimiter string, columns string) (mempool.Codec, error) {
+	csvCodec, ok := codec.(mempool.CSVCodec)
+	if !ok {
+		return codec, nil
+	}
+	if utf8.RuneCountInString(delimiter) != 1 {
+		return nil, fmt.Errorf("Invalid CSV delimiter [%s]", delimiter)
+	}
+	csvCodec.Comma, _ = utf8.DecodeRuneInString(delimiter)
+	if columns != "" {
+		csvCodec.Columns = strings.Split(columns, ",")
+	}
+	return csvCodec, nil
+}
+
+// writeRejected writes the rejected records as they are, so they can be fixed and read again
+// The CSV records are written under the header of the input
+func writeRejected(path string, inputPath string, codec mempool.Codec, rejected []*mempool.ParseError) error {
+	var header string
+	if csvCodec, ok := codec.(mempool.CSVCodec); ok && len(rejected) > 0 {
+		var err error
+		if header, err = readCSVHeader(inputPath, csvCodec); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if header != "" {
+		if _, err = io.WriteString(file, header); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	for _, parseErr := range rejected {
+		if _, err = fmt.Fprintln(file, parseErr.Text); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	return file.Close()
+}
+
+// readCSVHeader returns the header row of the CSV input encoded back with its delimiter and the line break
+func readCSVHeader(path string, codec mempool.CSVCodec) (string, error) {
+	input, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer input.Close()
+
+	reader := csv.NewReader(input)
+	reader.Comma = codec.Comma
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	writer := csv.NewWriter(&b)
+	writer.Comma = codec.Comma
+	if err = writer.Write(header); err != nil {
+		return "", err
+	}
+	writer.Flush()
+	return b.String(), writer.Error()
+}
@@ -0,0 +1,123 @@
+package mempool
+
+import "math/big"
+
+// SetBaseFee sets the base fee per gas of the pool, e.g. once a block is mined, and re-prioritizes all the
+// transactions by their effective tips. The transactions whose max fee per gas is below the base fee are parked,
+// the ones which are no longer below it can be popped again. A nil base fee removes it
+func (m *MemPool) SetBaseFee(baseFee *big.Rat) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.baseFee = copyRat(baseFee)
+	m.reprioritize()
+	if m.queue.Len() > 0 {
+		m.wakeConsumers()
+	}
+}
+
+// BaseFee returns the base fee per gas of the pool, nil if there is none
+func (m *MemPool) BaseFee() *big.Rat {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return copyRat(m.baseFee)
+}
+
+// ParkedLen returns the number of the transactions whose max fee per gas is below the base fee
+func (m *MemPool) ParkedLen() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.parked.Len()
+}
+
+func copyRat(r *big.Rat) *big.Rat {
+	if r == nil {
+		return nil
+	}
+	return new(big.Rat).Set(r)
+}
+
+// priority returns the total effective tip of the transaction with the base fee of the pool
+// Without a base fee it is the total fee of the transaction
+func (m *MemPool) priority(tx Transaction) *big.Rat {
+	if m.baseFee == nil {
+		return tx.exactFee()
+	}
+	tip, _ := tx.EffectiveTip(m.baseFee)
+	return tip.Mul(tip, new(big.Rat).SetInt64(int64(tx.Gas)))
+}
+
+// parks reports whether the max fee per gas of the transaction is below the base fee of the pool
+func (m *MemPool) parks(tx Transaction) bool {
+	if m.baseFee == nil {
+		return false
+	}
+	_, includable := tx.EffectiveTip(m.baseFee)
+	return !includable
+}
+
+// place adds the entry to the parked set if its transaction is parked, otherwise to the queue
+func (m *MemPool) place(e poolEntry) {
+	if m.parks(e.tx) {
+		m.parked.Push(e)
+	} else {
+		m.queue.Push(e)
+	}
+	if sn, ok := m.unorderedNonce(e.tx); ok {
+		m.senderNonces[sn] = entryKey(e)
+	}
+}
+
+// unplace removes the entry with the given key from the queue or from the parked set
+func (m *MemPool) unplace(key string) (poolEntry, bool) {
+	e, ok := m.queue.Remove(key)
+	if !ok {
+		e, ok = m.parked.Remove(key)
+	}
+	if ok {
+		m.forgetNonce(e)
+	}
+	return e, ok
+}
+
+// reprioritize calculates the priorities of all the entries again and rebuilds the queues with them
+func (m *MemPool) reprioritize() {
+	for _, a := range m.accounts {
+		for nonce, e := range a.txs {
+			e.priority = m.priority(e.tx)
+			a.txs[nonce] = e
+		}
+	}
+	// The entries of the accounts are taken from the accounts, which are already updated
+	entry := func(e poolEntry) poolEntry {
+		if sn, ok := m.nonces[entryKey(e)]; ok {
+			return m.accounts[sn.sender].txs[sn.nonce]
+		}
+		e.priority = m.priority(e.tx)
+		return e
+	}
+
+	var poppable, parked, queued []poolEntry
+	collect := func(e poolEntry) bool {
+		e = entry(e)
+		if m.parks(e.tx) {
+			parked = append(parked, e)
+		} else {
+			poppable = append(poppable, e)
+		}
+		return true
+	}
+	m.queue.Ascend(collect)
+	m.parked.Ascend(collect)
+	m.queued.Ascend(func(e poolEntry) bool {
+		queued = append(queued, entry(e))
+		return true
+	})
+
+	m.queue, m.parked, m.queued = m.newQueue(), m.newQueue(), m.newQueue()
+	m.queue.PushBatch(poppable)
+	m.parked.PushBatch(parked)
+	m.queued.PushBatch(queued)
+}
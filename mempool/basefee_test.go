@@ -0,0 +1,156 @@
+package mempool
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func dynamicTransaction(t *testing.T, hash string, maxFee, maxPriorityFee int, extra string) Transaction {
+	line := fmt.Sprintf("TxHash=%s Gas=1 Signature=CD MaxFeePerGas=%d MaxPriorityFeePerGas=%d %s", hash, maxFee, maxPriorityFee, extra)
+	tx, err := ReadTransaction(line)
+	require.NoError(t, err)
+	return tx
+}
+
+func TestMemPool_BaseFee(t *testing.T) {
+	txs := []Transaction{
+		dynamicTransaction(t, "capped", 12, 5, ""),
+		dynamicTransaction(t, "tip", 100, 3, ""),
+		dynamicTransaction(t, "low", 9, 9, ""),
+		{Hash: "flat", Gas: 1, FeePerGas: "14", Signature: "CD"},
+	}
+	tests := map[string]struct {
+		baseFee        *big.Rat
+		expectedOrder  []string
+		expectedParked int
+	}{
+		"without base fee": {
+			expectedOrder: []string{"flat", "low", "capped", "tip"},
+		},
+		"zero base fee": {
+			baseFee:       new(big.Rat),
+			expectedOrder: []string{"flat", "low", "capped", "tip"},
+		},
+		"tips are capped by the max fee": {
+			baseFee:        big.NewRat(10, 1),
+			expectedOrder:  []string{"flat", "tip", "capped"},
+			expectedParked: 1,
+		},
+		"all parked": {
+			baseFee:        big.NewRat(200, 1),
+			expectedParked: 4,
+		},
+	}
+
+	for tName, tc := range tests {
+		tc := tc
+		t.Run(tName, func(t *testing.T) {
+			memPool := NewMemPool(WithBaseFee(tc.baseFee))
+			for _, tx := range txs {
+				require.True(t, mustPush(t, memPool, tx).Admitted)
+			}
+			require.Equal(t, len(txs), memPool.Len())
+			require.Equal(t, tc.expectedParked, memPool.ParkedLen())
+
+			var output bytes.Buffer
+			require.NoError(t, memPool.WriteTransactions(&output))
+			lines := strings.Split(output.String(), "\n")
+			require.Len(t, lines, len(txs))
+
+			order := popAll(memPool)
+			require.Equal(t, tc.expectedOrder, order)
+			require.Equal(t, tc.expectedParked, memPool.Len())
+			for i, hash := range order {
+				require.True(t, strings.HasPrefix(lines[i], "TxHash="+hash+" "))
+			}
+		})
+	}
+}
+
+func TestMemPool_SetBaseFee(t *testing.T) {
+	memPool := NewMemPool()
+	memPool.Push(dynamicTransaction(t, "capped", 12, 5, ""))
+	memPool.Push(dynamicTransaction(t, "tip", 100, 3, ""))
+	memPool.Push(dynamicTransaction(t, "low", 9, 9, ""))
+
+	tx, _ := memPool.Peek()
+	require.Equal(t, "low", tx.Hash)
+	require.Nil(t, memPool.BaseFee())
+
+	memPool.SetBaseFee(big.NewRat(10, 1))
+	require.Equal(t, big.NewRat(10, 1), memPool.BaseFee())
+	require.Equal(t, 1, memPool.ParkedLen())
+	require.Equal(t, 3, memPool.Len())
+	require.True(t, memPool.Contains("low"))
+	tx, _ = memPool.Peek()
+	require.Equal(t, "tip", tx.Hash)
+
+	memPool.SetBaseFee(big.NewRat(8, 1))
+	require.Equal(t, 0, memPool.ParkedLen())
+	require.Equal(t, []string{"capped", "tip", "low"}, popAll(memPool))
+}
+
+func TestMemPool_BaseFeeRemoveParked(t *testing.T) {
+	memPool := NewMemPool(WithBaseFee(big.NewRat(10, 1)))
+	memPool.Push(dynamicTransaction(t, "low", 9, 9, ""))
+
+	_, ok := memPool.Pop()
+	require.False(t, ok)
+	tx, ok := memPool.Get("LOW")
+	require.True(t, ok)
+	require.Equal(t, "9", tx.MaxFeePerGas)
+
+	_, ok = memPool.Remove("low")
+	require.True(t, ok)
+	require.Equal(t, 0, memPool.Len())
+	require.Equal(t, 0, memPool.ParkedLen())
+}
+
+func TestMemPool_BaseFeeCapacityEvictsParkedFirst(t *testing.T) {
+	var evictions []Eviction
+	memPool := NewMemPool(WithBaseFee(big.NewRat(10, 1)), WithCapacity(2), WithEvictionHook(func(eviction Eviction) {
+		evictions = append(evictions, eviction)
+	}))
+	parked := dynamicTransaction(t, "parked", 9, 9, "")
+	memPool.Push(parked)
+	memPool.Push(dynamicTransaction(t, "tip", 100, 3, ""))
+
+	result := mustPush(t, memPool, dynamicTransaction(t, "tip2", 100, 2, ""))
+	require.True(t, result.Admitted)
+	require.Equal(t, &parked, result.Evicted)
+	require.Equal(t, []Eviction{{Transaction: parked, Reason: EvictionCapacity}}, evictions)
+
+	require.NoError(t, memPool.PushBatch([]Transaction{dynamicTransaction(t, "tip1", 100, 1, "")}))
+	require.Equal(t, []string{"tip", "tip2"}, popAll(memPool))
+}
+
+func TestMemPool_BaseFeeNonceOrdering(t *testing.T) {
+	memPool := NewMemPool(WithNonceOrdering(), WithBaseFee(big.NewRat(10, 1)))
+	memPool.Push(dynamicTransaction(t, "alice0", 9, 9, "Sender=alice Nonce=0"))
+	memPool.Push(dynamicTransaction(t, "alice1", 100, 50, "Sender=alice Nonce=1"))
+	memPool.Push(dynamicTransaction(t, "bob0", 100, 1, "Sender=bob Nonce=0"))
+	memPool.Push(dynamicTransaction(t, "bob1", 8, 8, "Sender=bob Nonce=1"))
+
+	// The parked transactions block the following ones of their senders
+	var output bytes.Buffer
+	require.NoError(t, memPool.WriteTransactions(&output))
+	var order []string
+	for _, line := range strings.Split(output.String(), "\n") {
+		tx, err := ReadTransaction(line)
+		require.NoError(t, err)
+		order = append(order, tx.Hash)
+	}
+	require.Equal(t, []string{"bob0", "alice0", "alice1", "bob1"}, order)
+	require.Equal(t, []string{"bob0"}, popAll(memPool))
+	require.Equal(t, 3, memPool.Len())
+	require.Equal(t, 2, memPool.ParkedLen())
+
+	memPool.SetBaseFee(big.NewRat(1, 1))
+	require.Equal(t, 0, memPool.ParkedLen())
+	require.Equal(t, []string{"alice0", "alice1", "bob1"}, popAll(memPool))
+}
@@ -0,0 +1,398 @@
+package mempool
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const (
+	// binaryVersion is the version byte starting every record of the binary codec
+	binaryVersion = 1
+	// maxBinaryRecordSize protects the decoder from allocating a huge buffer for a corrupted record length
+	maxBinaryRecordSize = 1 << 20
+
+	// The flags telling whether the hash and the signature are stored as raw bytes or as strings
+	// and whether the fee per gas is omitted or stored as a string
+	binaryRawHash      = 1 << 0
+	binaryRawSignature = 1 << 1
+	binaryNoFee        = 1 << 2
+	binaryTextFee      = 1 << 3
+)
+
+var (
+	ErrUnsupportedVersion = errors.New("Unsupported binary record version")
+	ErrChecksumMismatch   = errors.New("Checksum mismatch")
+	ErrMalformedRecord    = errors.New("Malformed binary record")
+)
+
+// BinaryCodec is a compact length-prefixed binary format of the transactions
+// Every record is the version byte, the uvarint length of the payload, the payload and the big-endian CRC-32 (IEEE)
+// of the payload. The payload is
+//   - a flags byte telling whether the hash and the signature are raw bytes and whether the fee per gas is omitted
+//     or a string
+//   - the hash as HashSize raw bytes, if it is upper case hex of that size, otherwise as a string
+//   - the gas as a varint
+//   - the fee per gas as the uvarint scale and the unscaled integer, so that the fee is unscaled / 10^scale, unless
+//     it is omitted by a transaction with the dynamic fee fields. A fee which is not written as unscaled / 10^scale
+//     with scale fractional digits, e.g. one with an exponent, is stored as a string, so its text is kept
+//   - the signature as SignatureSize raw bytes or as a string like the hash
+//   - the uvarint number of the optional fields followed by their keys and values as strings, i.e. Sender,
+//     Nonce, the dynamic fee fields and the extension fields in their order
+//
+// The strings and the unscaled integer are prefixed with their uvarint length, the integer is the sign byte
+// followed by the big-endian magnitude
+type BinaryCodec struct{}
+
+func (BinaryCodec) Name() string {
+	return "binary"
+}
+
+func (BinaryCodec) NewDecoder(reader io.Reader, options ParseOptions) Decoder {
+	return &binaryDecoder{reader: bufio.NewReader(reader), options: options}
+}
+
+func (BinaryCodec) NewEncoder(writer io.Writer) Encoder {
+	return &binaryEncoder{writer: bufio.NewWriter(writer)}
+}
+
+type binaryDecoder struct {
+	reader  *bufio.Reader
+	options ParseOptions
+	// number is the 1-based number of the last read record
+	number int
+	// record is the hex of the last read record
+	record string
+}
+
+func (d *binaryDecoder) Decode() (Transaction, error) {
+	version, err := d.reader.ReadByte()
+	if err != nil {
+		return Transaction{}, err
+	}
+	d.number++
+	if version != binaryVersion {
+		return Transaction{}, fmt.Errorf("%w %d in record %d", ErrUnsupportedVersion, version, d.number)
+	}
+	size, err := binary.ReadUvarint(d.reader)
+	if err != nil {
+		return Transaction{}, unexpectedEOF(err)
+	}
+	if size > maxBinaryRecordSize {
+		return Transaction{}, fmt.Errorf("%w: record %d of %d bytes", ErrMalformedRecord, d.number, size)
+	}
+	payload := make([]byte, size+crc32.Size)
+	if _, err = io.ReadFull(d.reader, payload); err != nil {
+		return Transaction{}, unexpectedEOF(err)
+	}
+	payload, checksum := payload[:size], payload[size:]
+	var header [1 + binary.MaxVarintLen64]byte
+	header[0] = version
+	n := 1 + binary.PutUvarint(header[1:], size)
+	d.record = hex.EncodeToString(header[:n]) + hex.EncodeToString(payload) + hex.EncodeToString(checksum)
+
+	// The record is complete, so from here on the errors are related only to this record
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(checksum) {
+		return Transaction{}, &ParseError{Line: d.number, Text: d.record, Err: ErrChecksumMismatch}
+	}
+	tokensMap, err := decodeBinaryPayload(payload)
+	if err != nil {
+		return Transaction{}, &ParseError{Line: d.number, Text: d.record, Err: ErrMalformedRecord, detail: err.Error()}
+	}
+	tx, err := parseTokens(tokensMap, d.record, d.options)
+	if err != nil {
+		return tx, atLine(err, d.number, d.record)
+	}
+	return tx, nil
+}
+
+func (d *binaryDecoder) Record() (int, string) {
+	return d.number, d.record
+}
+
+// unexpectedEOF reports the end of the input in the middle of a record as io.ErrUnexpectedEOF
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// decodeBinaryPayload decodes the fields of the payload into the tokens, so they are validated like the text ones
+func decodeBinaryPayload(payload []byte) (map[string]token, error) {
+	r := bytes.NewReader(payload)
+	flags, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	tokensMap := make(map[string]token)
+	hash, err := readBinaryHex(r, flags&binaryRawHash != 0, HashSize)
+	if err != nil {
+		return nil, fmt.Errorf("hash: %w", err)
+	}
+	tokensMap[KeyHash] = token{key: KeyHash, value: hash}
+
+	gas, err := binary.ReadVarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("gas: %w", err)
+	}
+	tokensMap[KeyGas] = token{key: KeyGas, value: strconv.FormatInt(gas, 10)}
+
+	if flags&binaryNoFee == 0 {
+		var fee string
+		if flags&binaryTextFee != 0 {
+			fee, err = readBinaryString(r)
+		} else {
+			fee, err = readBinaryFee(r)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("fee: %w", err)
+		}
+		tokensMap[KeyFee] = token{key: KeyFee, value: fee}
+	}
+
+	signature, err := readBinaryHex(r, flags&binaryRawSignature != 0, SignatureSize)
+	if err != nil {
+		return nil, fmt.Errorf("signature: %w", err)
+	}
+	tokensMap[KeySignature] = token{key: KeySignature, value: signature}
+
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("fields: %w", err)
+	}
+	for i := uint64(0); i < count; i++ {
+		key, err := readBinaryString(r)
+		if err != nil {
+			return nil, fmt.Errorf("fields: %w", err)
+		}
+		value, err := readBinaryString(r)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", key, err)
+		}
+		addToken(tokensMap, token{key: key, value: value})
+	}
+
+	if r.Len() > 0 {
+		return nil, fmt.Errorf("%d bytes after the fields", r.Len())
+	}
+	return tokensMap, nil
+}
+
+// readBinaryHex reads either size raw bytes returning their upper case hex or a string
+func readBinaryHex(r *bytes.Reader, raw bool, size int) (string, error) {
+	if !raw {
+		return readBinaryString(r)
+	}
+	b := make([]byte, size)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	return strings.ToUpper(hex.EncodeToString(b)), nil
+}
+
+func readBinaryString(r *bytes.Reader) (string, error) {
+	b, err := readBinaryBytes(r)
+	return string(b), err
+}
+
+func readBinaryBytes(r *bytes.Reader) ([]byte, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if size > uint64(r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := make([]byte, size)
+	_, err = io.ReadFull(r, b)
+	return b, err
+}
+
+// readBinaryFee reads the scale and the unscaled integer of the fee and formats it with scale fractional digits
+func readBinaryFee(r *bytes.Reader) (string, error) {
+	scale, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", err
+	}
+	if scale > maxBinaryRecordSize {
+		return "", fmt.Errorf("scale %d is too large", scale)
+	}
+	b, err := readBinaryBytes(r)
+	if err != nil {
+		return "", err
+	}
+	if len(b) == 0 || b[0] > 1 {
+		return "", errors.New("invalid sign of the unscaled value")
+	}
+	unscaled := new(big.Int).SetBytes(b[1:])
+	if b[0] == 1 {
+		unscaled.Neg(unscaled)
+	}
+	return formatScaled(unscaled, int(scale)), nil
+}
+
+// formatScaled formats unscaled / 10^scale with scale fractional digits
+func formatScaled(unscaled *big.Int, scale int) string {
+	fee := new(big.Rat).SetFrac(unscaled, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil))
+	return fee.FloatString(scale)
+}
+
+// binaryRecord frames the payload into a record
+func binaryRecord(payload []byte) []byte {
+	var record bytes.Buffer
+	record.WriteByte(binaryVersion)
+	writeUvarint(&record, uint64(len(payload)))
+	record.Write(payload)
+	var checksum [crc32.Size]byte
+	binary.BigEndian.PutUint32(checksum[:], crc32.ChecksumIEEE(payload))
+	record.Write(checksum[:])
+	return record.Bytes()
+}
+
+type binaryEncoder struct {
+	writer *bufio.Writer
+}
+
+func (e *binaryEncoder) Encode(tx Transaction) error {
+	payload, err := encodeBinaryPayload(tx)
+	if err != nil {
+		return err
+	}
+	_, err = e.writer.Write(binaryRecord(payload))
+	return err
+}
+
+func (e *binaryEncoder) Close() error {
+	return e.writer.Flush()
+}
+
+func encodeBinaryPayload(tx Transaction) ([]byte, error) {
+	var unscaled *big.Int
+	var scale int
+	textFee := false
+	if tx.hasFeePerGas() {
+		if _, ok := parseDecimal(tx.FeePerGas); !ok {
+			return nil, fmt.Errorf("%w %s [%s]", ErrInvalidValueForField, KeyFee, tx.FeePerGas)
+		}
+		// A fee with more fractional digits than decimalParts scales to, like 1e-40, is written as text too
+		var ok bool
+		unscaled, scale, ok = decimalParts(tx.FeePerGas)
+		textFee = !ok || formatScaled(unscaled, scale) != tx.FeePerGas
+	}
+
+	hash, rawHash := rawHex(tx.Hash, HashSize)
+	signature, rawSignature := rawHex(tx.Signature, SignatureSize)
+	var flags byte
+	if rawHash {
+		flags |= binaryRawHash
+	}
+	if rawSignature {
+		flags |= binaryRawSignature
+	}
+	if !tx.hasFeePerGas() {
+		flags |= binaryNoFee
+	}
+	if textFee {
+		flags |= binaryTextFee
+	}
+
+	var payload bytes.Buffer
+	payload.WriteByte(flags)
+	writeBinaryHex(&payload, hash, rawHash)
+	writeVarint(&payload, int64(tx.Gas))
+	if textFee {
+		writeBinaryBytes(&payload, []byte(tx.FeePerGas))
+	} else if unscaled != nil {
+		writeUvarint(&payload, uint64(scale))
+		sign := byte(0)
+		if unscaled.Sign() < 0 {
+			sign = 1
+		}
+		writeBinaryBytes(&payload, append([]byte{sign}, unscaled.Bytes()...))
+	}
+	writeBinaryHex(&payload, signature, rawSignature)
+
+	fields := optionalFields(tx)
+	writeUvarint(&payload, uint64(len(fields)))
+	for _, field := range fields {
+		writeBinaryBytes(&payload, []byte(field.Key))
+		writeBinaryBytes(&payload, []byte(field.Value))
+	}
+	return payload.Bytes(), nil
+}
+
+// rawHex returns the bytes of the upper case hex string of the given size, which is stored in the raw form
+// Any other string is returned as it is, so it is decoded unchanged
+func rawHex(s string, size int) ([]byte, bool) {
+	if len(s) != 2*size || strings.ToUpper(s) != s {
+		return []byte(s), false
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return []byte(s), false
+	}
+	return b, true
+}
+
+func writeBinaryHex(buf *bytes.Buffer, b []byte, raw bool) {
+	if raw {
+		buf.Write(b)
+		return
+	}
+	writeBinaryBytes(buf, b)
+}
+
+func writeBinaryBytes(buf *bytes.Buffer, b []byte) {
+	writeUvarint(buf, uint64(len(b)))
+	buf.Write(b)
+}
+
+func writeUvarint(buf *bytes.Buffer, v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	buf.Write(b[:binary.PutUvarint(b[:], v)])
+}
+
+func writeVarint(buf *bytes.Buffer, v int64) {
+	var b [binary.MaxVarintLen64]byte
+	buf.Write(b[:binary.PutVarint(b[:], v)])
+}
+
+// decimalParts returns the unscaled integer and the scale of the decimal number, so that it is unscaled / 10^scale
+// The scale is the number of the fractional digits of the number, including the trailing zeros
+func decimalParts(s string) (*big.Int, int, bool) {
+	fee, ok := parseDecimal(s)
+	if !ok {
+		return nil, 0, false
+	}
+
+	scale := 0
+	if mantissa := strings.TrimLeft(s, "+-"); !strings.ContainsAny(mantissa, "eE") {
+		if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+			scale = len(mantissa) - i - 1
+		}
+	}
+	ten := big.NewInt(10)
+	pow := new(big.Int).Exp(ten, big.NewInt(int64(scale)), nil)
+	rem := new(big.Int)
+	// A number in the exponent form can have more fractional digits than written
+	for rem.Mod(pow, fee.Denom()).Sign() != 0 {
+		if scale >= maxDecimalDigits {
+			return nil, 0, false
+		}
+		pow.Mul(pow, ten)
+		scale++
+	}
+
+	unscaled := new(big.Int).Mul(fee.Num(), pow)
+	return unscaled.Quo(unscaled, fee.Denom()), scale, true
+}
@@ -0,0 +1,232 @@
+package mempool
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func encodeBinary(t *testing.T, txs ...Transaction) []byte {
+	var buf bytes.Buffer
+	encoder := BinaryCodec{}.NewEncoder(&buf)
+	for _, tx := range txs {
+		require.NoError(t, encoder.Encode(tx))
+	}
+	require.NoError(t, encoder.Close())
+	return buf.Bytes()
+}
+
+func TestBinaryCodec_Encode(t *testing.T) {
+	tx := Transaction{Hash: "AB", Gas: 1000, FeePerGas: "0.50", Signature: "cd", Sender: "al"}
+	require.Equal(t, "01"+"18"+
+		"00"+"024142"+"d00f"+"02"+"020032"+"026364"+"01"+"0653656e646572"+"02616c"+
+		"4ea43413",
+		hex.EncodeToString(encodeBinary(t, tx)))
+
+	tx = testTransaction(t, 1)
+	record := encodeBinary(t, tx)
+	// The raw hash and signature take HashSize and SignatureSize bytes instead of two hex digits per byte
+	require.Len(t, record, 1+1+1+HashSize+2+1+3+SignatureSize+1+4)
+}
+
+func TestBinaryCodec_RoundTripFields(t *testing.T) {
+	tests := map[string]struct {
+		tx          Transaction
+		expectedFee string
+	}{
+		"raw hash and signature": {
+			tx:          testTransaction(t, 7),
+			expectedFee: "7",
+		},
+		"lower case hash is kept": {
+			tx:          Transaction{Hash: "ab" + testTransaction(t, 1).Hash[2:], Gas: 1, FeePerGas: "1", Signature: "CD"},
+			expectedFee: "1",
+		},
+		"trailing zeros are kept": {
+			tx:          Transaction{Hash: "AB", Gas: 1, FeePerGas: "0.1000", Signature: "CD"},
+			expectedFee: "0.1000",
+		},
+		"exponent is kept": {
+			tx:          Transaction{Hash: "AB", Gas: 1, FeePerGas: "9.556431783046658e-05", Signature: "CD"},
+			expectedFee: "9.556431783046658e-05",
+		},
+		"positive exponent": {
+			tx:          Transaction{Hash: "AB", Gas: 1, FeePerGas: "1.5E3", Signature: "CD"},
+			expectedFee: "1.5E3",
+		},
+		"sign is kept": {
+			tx:          Transaction{Hash: "AB", Gas: 1, FeePerGas: "+.5", Signature: "CD"},
+			expectedFee: "+.5",
+		},
+		"very small fee": {
+			tx:          Transaction{Hash: "AB", Gas: 1, FeePerGas: "1e-40", Signature: "CD"},
+			expectedFee: "1e-40",
+		},
+		"more than 36 fractional digits": {
+			tx:          Transaction{Hash: "AB", Gas: 1, FeePerGas: "0." + strings.Repeat("0", 39) + "1", Signature: "CD"},
+			expectedFee: "0." + strings.Repeat("0", 39) + "1",
+		},
+		"more than 36 digits": {
+			tx:          Transaction{Hash: "AB", Gas: 1, FeePerGas: strings.Repeat("9", 30) + "." + strings.Repeat("1", 30), Signature: "CD"},
+			expectedFee: strings.Repeat("9", 30) + "." + strings.Repeat("1", 30),
+		},
+		"negative values": {
+			tx:          Transaction{Hash: "AB", Gas: -1, FeePerGas: "-0.25", Signature: "CD", Sender: "bob"},
+			expectedFee: "-0.25",
+		},
+		"extension fields": {
+			tx:          Transaction{Hash: "AB", Gas: 1, FeePerGas: "1", Signature: "CD", Extensions: []Field{{Key: "Chain", Value: "4"}, {Key: "Memo", Value: ""}}},
+			expectedFee: "1",
+		},
+	}
+
+	for tName, tc := range tests {
+		tc := tc
+		t.Run(tName, func(t *testing.T) {
+			decoder := BinaryCodec{}.NewDecoder(bytes.NewReader(encodeBinary(t, tc.tx)), ParseOptions{})
+			tx, err := decoder.Decode()
+			require.NoError(t, err)
+			require.Equal(t, tc.tx.Hash, tx.Hash)
+			require.Equal(t, tc.tx.Gas, tx.Gas)
+			require.Equal(t, tc.expectedFee, tx.FeePerGas)
+			require.Equal(t, 0, tc.tx.Fee().Cmp(tx.Fee()))
+			require.Equal(t, tc.tx.Signature, tx.Signature)
+			require.Equal(t, tc.tx.Sender, tx.Sender)
+			require.Equal(t, tc.tx.Extensions, tx.Extensions)
+
+			_, err = decoder.Decode()
+			require.Equal(t, io.EOF, err)
+		})
+	}
+}
+
+func TestBinaryCodec_EncodeInvalidFee(t *testing.T) {
+	err := BinaryCodec{}.NewEncoder(io.Discard).Encode(Transaction{Hash: "AB", Gas: 1, FeePerGas: "x", Signature: "CD"})
+	require.ErrorIs(t, err, ErrInvalidValueForField)
+}
+
+func TestBinaryCodec_DecodeChecksumMismatch(t *testing.T) {
+	input := encodeBinary(t, testTransaction(t, 1), testTransaction(t, 2))
+	// Corrupt the gas of the first record
+	input[2+1+HashSize] ^= 0xFF
+
+	decoder := BinaryCodec{}.NewDecoder(bytes.NewReader(input), ParseOptions{})
+	_, err := decoder.Decode()
+	require.ErrorIs(t, err, ErrChecksumMismatch)
+	var parseErr *ParseError
+	require.ErrorAs(t, err, &parseErr)
+	require.Equal(t, 1, parseErr.Line)
+	number, text := decoder.Record()
+	require.Equal(t, 1, number)
+	require.Equal(t, hex.EncodeToString(input[:len(input)/2]), text)
+
+	tx, err := decoder.Decode()
+	require.NoError(t, err)
+	require.Equal(t, testTransaction(t, 2).Hash, tx.Hash)
+}
+
+func TestBinaryCodec_DecodeFatalErrors(t *testing.T) {
+	record := encodeBinary(t, testTransaction(t, 1))
+	tests := map[string]struct {
+		input         []byte
+		expectedError error
+	}{
+		"truncated record": {
+			input:         record[:len(record)-1],
+			expectedError: io.ErrUnexpectedEOF,
+		},
+		"truncated length": {
+			input:         []byte{binaryVersion},
+			expectedError: io.ErrUnexpectedEOF,
+		},
+		"unsupported version": {
+			input:         append([]byte{2}, record[1:]...),
+			expectedError: ErrUnsupportedVersion,
+		},
+		"too large record": {
+			input:         []byte{binaryVersion, 0xFF, 0xFF, 0xFF, 0xFF, 0x0F},
+			expectedError: ErrMalformedRecord,
+		},
+	}
+
+	for tName, tc := range tests {
+		tc := tc
+		t.Run(tName, func(t *testing.T) {
+			_, err := BinaryCodec{}.NewDecoder(bytes.NewReader(tc.input), ParseOptions{}).Decode()
+			require.ErrorIs(t, err, tc.expectedError)
+			var parseErr *ParseError
+			require.False(t, errors.As(err, &parseErr))
+		})
+	}
+}
+
+func TestBinaryCodec_DecodeMalformedPayload(t *testing.T) {
+	tests := map[string][]byte{
+		"empty":           {},
+		"truncated hash":  {binaryRawHash, 1, 2},
+		"trailing bytes":  {0, 1, 'A', 2, 0, 2, 0, 1, 1, 'C', 0, 0},
+		"invalid sign":    {0, 1, 'A', 2, 0, 2, 2, 1, 1, 'C', 0},
+		"string overflow": {0, 9, 'A'},
+	}
+
+	for tName, payload := range tests {
+		payload := payload
+		t.Run(tName, func(t *testing.T) {
+			_, err := BinaryCodec{}.NewDecoder(bytes.NewReader(binaryRecord(payload)), ParseOptions{}).Decode()
+			require.ErrorIs(t, err, ErrMalformedRecord)
+			var parseErr *ParseError
+			require.ErrorAs(t, err, &parseErr)
+		})
+	}
+}
+
+func TestDecimalParts(t *testing.T) {
+	tests := map[string]struct {
+		unscaled int64
+		scale    int
+	}{
+		"0.11134106816568039": {unscaled: 11134106816568039, scale: 17},
+		"1":                   {unscaled: 1, scale: 0},
+		"1.50":                {unscaled: 150, scale: 2},
+		"-2.5":                {unscaled: -25, scale: 1},
+		"9.5e-05":             {unscaled: 95, scale: 6},
+		"2e3":                 {unscaled: 2000, scale: 0},
+	}
+
+	for s, tc := range tests {
+		unscaled, scale, ok := decimalParts(s)
+		require.True(t, ok, s)
+		require.Equal(t, tc.unscaled, unscaled.Int64(), s)
+		require.Equal(t, tc.scale, scale, s)
+	}
+
+	_, _, ok := decimalParts("1/3")
+	require.False(t, ok)
+}
+
+func TestBinaryCodec_RoundTrip(t *testing.T) {
+	input, err := os.ReadFile("../transactions.txt")
+	require.NoError(t, err)
+
+	memPool := NewMemPool(WithTieBreak(TieBreakArrival), WithCapacity(10000))
+	require.NoError(t, memPool.ReadTransactions(bytes.NewReader(input)))
+	var binaryOutput bytes.Buffer
+	require.NoError(t, memPool.WriteTransactionsWith(&binaryOutput, BinaryCodec{}))
+	require.Less(t, binaryOutput.Len(), len(input)/2)
+
+	binaryPool := NewMemPool(WithTieBreak(TieBreakArrival), WithCapacity(10000))
+	report, err := binaryPool.ReadTransactionsWith(&binaryOutput, ReadOptions{Codec: BinaryCodec{}})
+	require.NoError(t, err)
+	require.Equal(t, 7500, report.Accepted)
+
+	var expected, actual bytes.Buffer
+	require.NoError(t, memPool.WriteTransactions(&expected))
+	require.NoError(t, binaryPool.WriteTransactions(&actual))
+	require.Equal(t, expected.String(), actual.String())
+}
@@ -0,0 +1,67 @@
+package mempool
+
+import "math/big"
+
+// Block is a template of a block built from the transactions of the pool
+type Block struct {
+	// Transactions are the transactions of the block in the order they would be popped
+	Transactions []Transaction
+	// Gas is the total gas of the transactions
+	Gas int
+	// Fees is the total fee of the transactions, i.e. their total effective tip if the pool has a base fee
+	Fees *big.Rat
+	// Leftover are the transactions of the pool which are not in the block in the order they would be written
+	Leftover []Transaction
+}
+
+// BuildBlock selects the transactions for a block with the gas limit from the highest to the lowest priority
+// A transaction which doesn't fit the remaining gas is skipped and the selection continues with the following ones,
+// which may be smaller. In the nonce ordering mode a transaction is selected only after the preceding one of its
+// sender, and the parked and the queued transactions are never selected.
+// The pool is not modified
+func (m *MemPool) BuildBlock(gasLimit int) Block {
+	return m.BuildBlockWith(gasLimit, PackGreedy)
+}
+
+// BuildBlockWith selects the transactions for a block with the gas limit by the packing strategy
+// The transactions of the block are ordered by priority, the ones of a sender in the order of their nonces.
+// The pool is not modified
+func (m *MemPool) BuildBlockWith(gasLimit int, strategy PackingStrategy) Block {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entries := m.pack(gasLimit, strategy)
+	selected := make(map[string]bool, len(entries))
+	block := Block{Fees: new(big.Rat)}
+	for _, e := range entries {
+		selected[entryKey(e)] = true
+		block.Transactions = append(block.Transactions, e.tx)
+		block.Gas += e.tx.Gas
+		block.Fees.Add(block.Fees, e.priority)
+	}
+
+	block.Leftover = make([]Transaction, 0, m.len()-len(block.Transactions))
+	m.descend(func(e poolEntry) bool {
+		if !selected[entryKey(e)] {
+			block.Leftover = append(block.Leftover, e.tx)
+		}
+		return true
+	})
+	return block
+}
+
+// blockEntries returns the selected entries in the order they would be popped
+func (m *MemPool) blockEntries(selected map[string]bool) []poolEntry {
+	entries := make([]poolEntry, 0, len(selected))
+	m.walkPoppable(func(e poolEntry) (bool, bool) {
+		if len(entries) == len(selected) {
+			return false, false
+		}
+		if !selected[entryKey(e)] {
+			return false, true
+		}
+		entries = append(entries, e)
+		return true, true
+	})
+	return entries
+}
@@ -0,0 +1,90 @@
+package mempool
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func hashes(txs []Transaction) []string {
+	var result []string
+	for _, tx := range txs {
+		result = append(result, tx.Hash)
+	}
+	return result
+}
+
+func TestMemPool_BuildBlock(t *testing.T) {
+	txs := []Transaction{
+		{Hash: "large", Gas: 8, FeePerGas: "10", Signature: "CD"},
+		{Hash: "medium", Gas: 5, FeePerGas: "10", Signature: "CD"},
+		{Hash: "small", Gas: 2, FeePerGas: "10", Signature: "CD"},
+		{Hash: "cheap", Gas: 1, FeePerGas: "1", Signature: "CD"},
+	}
+	tests := map[string]struct {
+		gasLimit         int
+		expectedHashes   []string
+		expectedGas      int
+		expectedFees     *big.Rat
+		expectedLeftover []string
+	}{
+		"the ones which don't fit are skipped": {
+			gasLimit:         11,
+			expectedHashes:   []string{"large", "small", "cheap"},
+			expectedGas:      11,
+			expectedFees:     big.NewRat(101, 1),
+			expectedLeftover: []string{"medium"},
+		},
+		"all fit": {
+			gasLimit:       100,
+			expectedHashes: []string{"large", "medium", "small", "cheap"},
+			expectedGas:    16,
+			expectedFees:   big.NewRat(151, 1),
+		},
+		"none fit": {
+			gasLimit:         0,
+			expectedFees:     new(big.Rat),
+			expectedLeftover: []string{"large", "medium", "small", "cheap"},
+		},
+	}
+
+	for tName, tc := range tests {
+		tc := tc
+		t.Run(tName, func(t *testing.T) {
+			memPool := NewMemPool()
+			require.NoError(t, memPool.PushBatch(txs))
+			var before bytes.Buffer
+			require.NoError(t, memPool.WriteTransactions(&before))
+
+			block := memPool.BuildBlock(tc.gasLimit)
+			require.Equal(t, tc.expectedHashes, hashes(block.Transactions))
+			require.Equal(t, tc.expectedGas, block.Gas)
+			require.Zero(t, tc.expectedFees.Cmp(block.Fees), "expected %s, got %s", tc.expectedFees.RatString(), block.Fees.RatString())
+			require.Equal(t, tc.expectedLeftover, hashes(block.Leftover))
+
+			// The pool is not modified
+			var after bytes.Buffer
+			require.NoError(t, memPool.WriteTransactions(&after))
+			require.Equal(t, before.String(), after.String())
+		})
+	}
+}
+
+func TestMemPool_BuildBlockNonceOrdering(t *testing.T) {
+	memPool := NewMemPool(WithNonceOrdering(), WithBaseFee(big.NewRat(10, 1)))
+	memPool.Push(Transaction{Hash: "alice0", Gas: 9, FeePerGas: "20", Signature: "CD", Sender: "alice", Nonce: 0, HasNonce: true})
+	memPool.Push(Transaction{Hash: "alice1", Gas: 1, FeePerGas: "50", Signature: "CD", Sender: "alice", Nonce: 1, HasNonce: true})
+	memPool.Push(nonceTransaction(t, "bob", 0, 12))
+	memPool.Push(nonceTransaction(t, "bob", 1, 30))
+	memPool.Push(nonceTransaction(t, "bob", 3, 40))
+	memPool.Push(nonceTransaction(t, "carol", 0, 5))
+
+	block := memPool.BuildBlock(5)
+	require.Equal(t, []string{"bob0-12", "bob1-30"}, hashes(block.Transactions))
+	require.Equal(t, 2, block.Gas)
+	require.Zero(t, big.NewRat(22, 1).Cmp(block.Fees))
+	require.Equal(t, []string{"alice0", "alice1", "carol0-5", "bob3-40"}, hashes(block.Leftover))
+	require.Equal(t, 6, memPool.Len())
+}
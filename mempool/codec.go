@@ -0,0 +1,125 @@
+package mempool
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var ErrUnknownCodec = errors.New("Unknown codec")
+
+// Codec is a wire format of the transactions
+type Codec interface {
+	// Name identifies the codec in the registry
+	Name() string
+	// NewDecoder creates a decoder of the transactions from the reader validating them according to the options
+	NewDecoder(reader io.Reader, options ParseOptions) Decoder
+	// NewEncoder creates an encoder of the transactions to the writer
+	NewEncoder(writer io.Writer) Encoder
+}
+
+// Decoder reads the transactions from a stream record by record
+type Decoder interface {
+	// Decode returns the next transaction or io.EOF when there are no more records
+	// A *ParseError means that only the record is invalid, so the decoding can go on with the next one
+	Decode() (Transaction, error)
+	// Record returns the 1-based number and the text of the last decoded record, which is used to report the errors
+	// found in the transaction after it is decoded
+	Record() (int, string)
+}
+
+// Encoder writes the transactions to a stream
+type Encoder interface {
+	Encode(tx Transaction) error
+	// Close writes the buffered data, if any, the underlying writer is not closed
+	Close() error
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = make(map[string]Codec)
+)
+
+func init() {
+	RegisterCodec(TextCodec{})
+	RegisterCodec(JSONLinesCodec{})
+	RegisterCodec(CSVCodec{})
+	RegisterCodec(BinaryCodec{})
+	RegisterCodec(RLPCodec{})
+}
+
+// RegisterCodec makes the codec available by its name
+// It panics if a codec with the same name is already registered
+func RegisterCodec(codec Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	if _, ok := codecs[codec.Name()]; ok {
+		panic(fmt.Sprintf("codec %s is already registered", codec.Name()))
+	}
+	codecs[codec.Name()] = codec
+}
+
+// LookupCodec returns the registered codec with the given name
+func LookupCodec(name string) (Codec, error) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	codec, ok := codecs[name]
+	if !ok {
+		return nil, fmt.Errorf("%w [%s]", ErrUnknownCodec, name)
+	}
+	return codec, nil
+}
+
+// CodecNames returns the sorted names of the registered codecs
+func CodecNames() []string {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	names := make([]string, 0, len(codecs))
+	for name := range codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lineReader reads the non-blank lines of the input keeping track of their numbers
+type lineReader struct {
+	reader *bufio.Reader
+	isEof  bool
+	// number is the 1-based number of the last read line
+	number int
+	line   string
+}
+
+func newLineReader(reader io.Reader) *lineReader {
+	return &lineReader{reader: bufio.NewReader(reader)}
+}
+
+// next returns the next non-blank line without the line break or io.EOF when the input is over
+func (r *lineReader) next() (string, error) {
+	for !r.isEof {
+		line, err := r.reader.ReadString('\n')
+		if err != nil {
+			if err != io.EOF {
+				return "", err
+			}
+			r.isEof = true
+		}
+		r.number++
+		line = strings.TrimRight(line, "\r\n")
+		// Skip whitespace lines
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		r.line = line
+		return line, nil
+	}
+	return "", io.EOF
+}
@@ -0,0 +1,88 @@
+package mempool
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCodec struct {
+	TextCodec
+}
+
+func (testCodec) Name() string {
+	return "test"
+}
+
+func TestRegisterCodec(t *testing.T) {
+	require.Equal(t, []string{"binary", "csv", "jsonl", "rlp", "text"}, CodecNames())
+
+	codec, err := LookupCodec("jsonl")
+	require.NoError(t, err)
+	require.Equal(t, JSONLinesCodec{}, codec)
+
+	_, err = LookupCodec("test")
+	require.ErrorIs(t, err, ErrUnknownCodec)
+	require.EqualError(t, err, "Unknown codec [test]")
+
+	RegisterCodec(testCodec{})
+	defer func() {
+		codecsMu.Lock()
+		delete(codecs, "test")
+		codecsMu.Unlock()
+	}()
+	codec, err = LookupCodec("test")
+	require.NoError(t, err)
+	require.Equal(t, testCodec{}, codec)
+
+	require.Panics(t, func() {
+		RegisterCodec(testCodec{})
+	})
+}
+
+func TestLineReader(t *testing.T) {
+	reader := newLineReader(strings.NewReader("a\r\n\n  \t\nb\nc"))
+
+	var lines []string
+	var numbers []int
+	for {
+		line, err := reader.next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		lines = append(lines, line)
+		numbers = append(numbers, reader.number)
+	}
+	require.Equal(t, []string{"a", "b", "c"}, lines)
+	require.Equal(t, []int{1, 4, 5}, numbers)
+}
+
+func TestCodecs_PreserveExtensions(t *testing.T) {
+	input := strings.Join([]string{
+		testTransaction(t, 2).String() + " Chain=4 Memo=",
+		testTransaction(t, 1).String() + " Sender=alice Nonce=0 Tag=a Chain=5",
+		"TxHash=EF Gas=1 Signature=CD MaxFeePerGas=5e-1 MaxPriorityFeePerGas=0.1",
+	}, "\n")
+
+	for _, name := range CodecNames() {
+		codec, err := LookupCodec(name)
+		require.NoError(t, err)
+		t.Run(name, func(t *testing.T) {
+			memPool := NewMemPool()
+			require.NoError(t, memPool.ReadTransactions(strings.NewReader(input)))
+			var encoded bytes.Buffer
+			require.NoError(t, memPool.WriteTransactionsWith(&encoded, codec))
+
+			decodedPool := NewMemPool()
+			_, err = decodedPool.ReadTransactionsWith(&encoded, ReadOptions{Codec: codec})
+			require.NoError(t, err)
+			var output bytes.Buffer
+			require.NoError(t, decodedPool.WriteTransactions(&output))
+			require.Equal(t, input, output.String())
+		})
+	}
+}
@@ -0,0 +1,323 @@
+package mempool
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ColumnTotalFee is the computed column with the exact total fee of the transaction
+	ColumnTotalFee = "TotalFee"
+	// ColumnRank is the computed column with the 1-based position of the transaction in the output
+	ColumnRank = "Rank"
+	// ColumnExtensions is the column with the extension fields which have no columns of their own,
+	// written as whitespace separated Key=Value tokens
+	ColumnExtensions = "Extensions"
+)
+
+var (
+	ErrInvalidHeader = errors.New("Invalid CSV header")
+	ErrInvalidRecord = errors.New("Invalid CSV record")
+	ErrInvalidColumn = errors.New("Invalid column")
+)
+
+// defaultCSVColumns are the columns of the CSV codec which has no columns set
+var defaultCSVColumns = []string{KeyHash, KeyGas, KeyFee, KeySignature, KeySender, KeyNonce, KeyMaxFee, KeyMaxPriorityFee, ColumnExtensions}
+
+// CSVCodec reads and writes the transactions as CSV with a header row naming the columns
+// The columns are the keys of the transaction fields, the computed columns ColumnTotalFee and ColumnRank and
+// ColumnExtensions. Any other column is an extension field, which is omitted from the transaction if its value is empty.
+// When decoding, the header defines the order of the columns and the computed columns are ignored
+type CSVCodec struct {
+	// Comma is the field delimiter, ',' is used if it is 0
+	Comma rune
+	// Columns are the written columns in their order, all the fields are written if it is empty
+	Columns []string
+}
+
+func (CSVCodec) Name() string {
+	return "csv"
+}
+
+func (c CSVCodec) NewDecoder(reader io.Reader, options ParseOptions) Decoder {
+	lines := &recordReader{reader: bufio.NewReader(reader)}
+	r := csv.NewReader(lines)
+	r.Comma = c.comma()
+	r.FieldsPerRecord = -1
+	return &csvDecoder{reader: r, lines: lines, options: options}
+}
+
+func (c CSVCodec) NewEncoder(writer io.Writer) Encoder {
+	w := csv.NewWriter(writer)
+	w.Comma = c.comma()
+	columns := c.Columns
+	if len(columns) == 0 {
+		columns = defaultCSVColumns
+	}
+	return &csvEncoder{writer: w, columns: columns}
+}
+
+func (c CSVCodec) comma() rune {
+	if c.Comma == 0 {
+		return ','
+	}
+	return c.Comma
+}
+
+type csvDecoder struct {
+	reader *csv.Reader
+	// lines keep the raw text of the last record, which is reported as it is, so a rejected record can be read again
+	lines   *recordReader
+	options ParseOptions
+	// columns are the keys of the fields by their index, empty for the ignored columns
+	columns []string
+	line    int
+	text    string
+}
+
+func (d *csvDecoder) Decode() (Transaction, error) {
+	if d.columns == nil {
+		if err := d.readHeader(); err != nil {
+			return Transaction{}, err
+		}
+	}
+
+	record, err := d.reader.Read()
+	text := d.lines.take()
+	if err != nil {
+		var csvErr *csv.ParseError
+		if errors.As(err, &csvErr) {
+			d.line, d.text = csvErr.StartLine, text
+			return Transaction{}, &ParseError{
+				Line:   csvErr.StartLine,
+				Column: csvErr.Column,
+				Text:   d.text,
+				Err:    ErrInvalidRecord,
+				detail: csvErr.Err.Error(),
+			}
+		}
+		return Transaction{}, err
+	}
+	d.line, _ = d.reader.FieldPos(0)
+	d.text = text
+
+	if len(record) != len(d.columns) {
+		return Transaction{}, &ParseError{
+			Line:   d.line,
+			Text:   d.text,
+			Err:    ErrInvalidRecord,
+			detail: fmt.Sprintf("expected %d fields, got %d", len(d.columns), len(record)),
+		}
+	}
+	tokensMap := make(map[string]token, len(record))
+	for i, value := range record {
+		_, column := d.reader.FieldPos(i)
+		switch d.columns[i] {
+		case "":
+		case ColumnExtensions:
+			if err = d.readExtensions(tokensMap, value, column); err != nil {
+				return Transaction{}, atLine(err, d.line, d.text)
+			}
+		default:
+			// An empty value means that the optional field is not set
+			if value == "" && !isRequiredField(d.columns[i]) {
+				continue
+			}
+			addToken(tokensMap, token{key: d.columns[i], value: value, column: column})
+		}
+	}
+	tx, err := parseTokens(tokensMap, d.text, d.options)
+	if err != nil {
+		return tx, atLine(err, d.line, d.text)
+	}
+	return tx, nil
+}
+
+// readHeader maps the columns of the header to the transaction fields
+// The header errors are not *ParseError, as none of the records can be read without the header
+func (d *csvDecoder) readHeader() error {
+	header, err := d.reader.Read()
+	d.lines.take()
+	if err != nil {
+		if err == io.EOF {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+	}
+
+	d.columns = make([]string, len(header))
+	found := make(map[string]bool)
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if name == ColumnTotalFee || name == ColumnRank {
+			continue
+		}
+		if name == "" || !isTokenText(name) {
+			return fmt.Errorf("%w: invalid column [%s]", ErrInvalidHeader, name)
+		}
+		if found[name] {
+			return fmt.Errorf("%w: duplicate column %s", ErrInvalidHeader, name)
+		}
+		found[name] = true
+		d.columns[i] = name
+	}
+	for _, name := range []string{KeyHash, KeyGas, KeyFee, KeySignature} {
+		if !found[name] {
+			return fmt.Errorf("%w: missing column %s", ErrInvalidHeader, name)
+		}
+	}
+	return nil
+}
+
+// readExtensions adds the Key=Value tokens of the extensions column to the tokens of the record
+// The tokens can't repeat the fields of the record
+func (d *csvDecoder) readExtensions(tokensMap map[string]token, value string, column int) error {
+	extensions, err := readTokens(value, true)
+	if err != nil {
+		return err
+	}
+	ordered := make([]token, len(extensions))
+	for _, t := range extensions {
+		ordered[t.index] = t
+	}
+	for _, t := range ordered {
+		if _, ok := tokensMap[t.key]; ok || isTransactionField(t.key) {
+			return &ParseError{Column: column + t.column - 1, Field: t.key, Token: t.value, Text: d.text, Err: ErrDuplicateKey}
+		}
+		t.column += column - 1
+		addToken(tokensMap, t)
+	}
+	return nil
+}
+
+// recordReader passes the input to the CSV reader one line at a time, so the reader never buffers the lines of the
+// next record and the lines read so far are the text of the last record
+type recordReader struct {
+	reader *bufio.Reader
+	// pending is the rest of the current line which didn't fit the buffer of the CSV reader
+	pending []byte
+	err     error
+	read    bytes.Buffer
+}
+
+func (r *recordReader) Read(p []byte) (int, error) {
+	if len(r.pending) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		line, err := r.reader.ReadSlice('\n')
+		if err != nil && err != bufio.ErrBufferFull {
+			r.err = err
+		}
+		if len(line) == 0 {
+			return 0, r.err
+		}
+		r.pending = append(r.pending[:0], line...)
+	}
+	n := copy(p, r.pending)
+	r.read.Write(r.pending[:n])
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// take returns the lines read since the last call without the empty lines the CSV reader skips before a record
+// and the line break after it
+func (r *recordReader) take() string {
+	text := strings.TrimLeft(r.read.String(), "\r\n")
+	r.read.Reset()
+	return strings.TrimRight(text, "\r\n")
+}
+
+func (d *csvDecoder) Record() (int, string) {
+	return d.line, d.text
+}
+
+type csvEncoder struct {
+	writer        *csv.Writer
+	columns       []string
+	headerWritten bool
+	// hasColumn tells which fields have columns of their own
+	hasColumn map[string]bool
+	rank      int
+}
+
+func (e *csvEncoder) Encode(tx Transaction) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	e.rank++
+	record := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		switch column {
+		case KeyHash:
+			record[i] = tx.Hash
+		case KeyGas:
+			record[i] = strconv.Itoa(tx.Gas)
+		case KeyFee:
+			record[i] = tx.FeePerGas
+		case KeySignature:
+			record[i] = tx.Signature
+		case KeySender:
+			record[i] = tx.Sender
+		case KeyMaxFee:
+			record[i] = tx.MaxFeePerGas
+		case KeyMaxPriorityFee:
+			record[i] = tx.MaxPriorityFeePerGas
+		case KeyNonce:
+			if tx.HasNonce {
+				record[i] = strconv.FormatUint(tx.Nonce, 10)
+			}
+		case ColumnTotalFee:
+			record[i] = formatDecimal(tx.exactFee())
+		case ColumnRank:
+			record[i] = strconv.Itoa(e.rank)
+		case ColumnExtensions:
+			record[i] = e.otherExtensions(tx)
+		default:
+			record[i], _ = tx.Extension(column)
+		}
+	}
+	return e.writer.Write(record)
+}
+
+// otherExtensions returns the Key=Value tokens of the extension fields which have no columns of their own
+func (e *csvEncoder) otherExtensions(tx Transaction) string {
+	var tokens []string
+	for _, field := range tx.Extensions {
+		if !e.hasColumn[field.Key] {
+			tokens = append(tokens, field.Key+"="+field.Value)
+		}
+	}
+	return strings.Join(tokens, " ")
+}
+
+// writeHeader writes the header once, it is written by Close even if there are no transactions
+func (e *csvEncoder) writeHeader() error {
+	if e.headerWritten {
+		return nil
+	}
+	e.hasColumn = make(map[string]bool)
+	for _, column := range e.columns {
+		if column == "" || !isTokenText(column) {
+			return fmt.Errorf("%w [%s]", ErrInvalidColumn, column)
+		}
+		e.hasColumn[column] = true
+	}
+	e.headerWritten = true
+	return e.writer.Write(e.columns)
+}
+
+func (e *csvEncoder) Close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.writer.Flush()
+	return e.writer.Error()
+}
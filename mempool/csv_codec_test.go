@@ -0,0 +1,173 @@
+package mempool
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVCodec_Encode(t *testing.T) {
+	tests := map[string]struct {
+		codec          CSVCodec
+		expectedOutput string
+		expectedError  error
+	}{
+		"default columns": {
+			codec:          CSVCodec{},
+			expectedOutput: "TxHash,Gas,FeePerGas,Signature,Sender,Nonce,MaxFeePerGas,MaxPriorityFeePerGas,Extensions\nAB,1000,0.5,CD,alice,7,,,Chain=1 Memo=x\nEF,3,1e-3,CD,,,,,\n",
+		},
+		"delimiter and computed columns": {
+			codec:          CSVCodec{Comma: '\t', Columns: []string{ColumnRank, KeyHash, ColumnTotalFee}},
+			expectedOutput: "Rank\tTxHash\tTotalFee\n1\tAB\t500\n2\tEF\t0.003\n",
+		},
+		"extension columns": {
+			codec:          CSVCodec{Columns: []string{KeyHash, "Memo", ColumnExtensions}},
+			expectedOutput: "TxHash,Memo,Extensions\nAB,x,Chain=1\nEF,,\n",
+		},
+		"invalid column": {
+			codec:         CSVCodec{Columns: []string{KeyHash, "Tx Chain"}},
+			expectedError: ErrInvalidColumn,
+		},
+	}
+
+	for tName, tc := range tests {
+		tc := tc
+		t.Run(tName, func(t *testing.T) {
+			var buf bytes.Buffer
+			encoder := tc.codec.NewEncoder(&buf)
+			err := encoder.Encode(Transaction{Hash: "AB", Gas: 1000, FeePerGas: "0.5", Signature: "CD", Sender: "alice", Nonce: 7, HasNonce: true, Extensions: []Field{
+				{Key: "Chain", Value: "1"},
+				{Key: "Memo", Value: "x"},
+			}})
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, encoder.Encode(Transaction{Hash: "EF", Gas: 3, FeePerGas: "1e-3", Signature: "CD"}))
+			require.NoError(t, encoder.Close())
+			require.Equal(t, tc.expectedOutput, buf.String())
+		})
+	}
+}
+
+func TestCSVCodec_EncodeEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, CSVCodec{}.NewEncoder(&buf).Close())
+	require.Equal(t, "TxHash,Gas,FeePerGas,Signature,Sender,Nonce,MaxFeePerGas,MaxPriorityFeePerGas,Extensions\n", buf.String())
+}
+
+func TestCSVCodec_Decode(t *testing.T) {
+	input := `Rank;Signature;FeePerGas;Gas;TxHash;Comment
+1;CD;0.5;1000;AB;"first;quoted"
+
+2;CD;x;1;EF;
+3;CD;1
+4;CD;1;2;"GH";`
+	decoder := CSVCodec{Comma: ';'}.NewDecoder(strings.NewReader(input), ParseOptions{})
+
+	tx, err := decoder.Decode()
+	require.NoError(t, err)
+	require.Equal(t, "TxHash=AB Gas=1000 FeePerGas=0.5 Signature=CD Comment=first;quoted", tx.String())
+	number, text := decoder.Record()
+	require.Equal(t, 2, number)
+	require.Equal(t, `1;CD;0.5;1000;AB;"first;quoted"`, text)
+
+	_, err = decoder.Decode()
+	require.EqualError(t, err, "Line 4, column 6: Invalid value for field FeePerGas [x]")
+
+	_, err = decoder.Decode()
+	require.EqualError(t, err, "Line 5: Invalid CSV record: expected 6 fields, got 3")
+
+	tx, err = decoder.Decode()
+	require.NoError(t, err)
+	require.Equal(t, "TxHash=GH Gas=2 FeePerGas=1 Signature=CD", tx.String())
+
+	_, err = decoder.Decode()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestCSVCodec_DecodeExtensions(t *testing.T) {
+	input := `Memo,TxHash,Gas,FeePerGas,Signature,Extensions,Rank
+x,AB,1,1,CD,Chain=1  Tag=a,1
+,EF,1,1,CD,,2
+y,GH,1,1,CD,Memo=z,3
+y,IJ,1,1,CD,Gas=2,4`
+	decoder := CSVCodec{}.NewDecoder(strings.NewReader(input), ParseOptions{})
+
+	tx, err := decoder.Decode()
+	require.NoError(t, err)
+	require.Equal(t, []Field{{Key: "Memo", Value: "x"}, {Key: "Chain", Value: "1"}, {Key: "Tag", Value: "a"}}, tx.Extensions)
+
+	tx, err = decoder.Decode()
+	require.NoError(t, err)
+	require.Nil(t, tx.Extensions)
+
+	for _, expectedColumn := range []int{13, 13} {
+		_, err = decoder.Decode()
+		require.ErrorIs(t, err, ErrDuplicateKey)
+		var parseErr *ParseError
+		require.ErrorAs(t, err, &parseErr)
+		require.Equal(t, expectedColumn, parseErr.Column)
+	}
+}
+
+func TestCSVCodec_DecodeInvalidRecord(t *testing.T) {
+	decoder := CSVCodec{}.NewDecoder(strings.NewReader("TxHash,Gas,FeePerGas,Signature\nAB,1,\"1\"x,CD\nEF,1,1,CD"), ParseOptions{})
+
+	_, err := decoder.Decode()
+	require.ErrorIs(t, err, ErrInvalidRecord)
+	var parseErr *ParseError
+	require.ErrorAs(t, err, &parseErr)
+	require.Equal(t, 2, parseErr.Line)
+	require.Equal(t, `AB,1,"1"x,CD`, parseErr.Text)
+
+	tx, err := decoder.Decode()
+	require.NoError(t, err)
+	require.Equal(t, "EF", tx.Hash)
+}
+
+func TestCSVCodec_DecodeInvalidHeader(t *testing.T) {
+	tests := map[string]string{
+		"missing column":   "TxHash,Gas,Signature\nAB,1,CD",
+		"duplicate column": "TxHash,Gas,FeePerGas,Signature,Gas\nAB,1,1,CD,1",
+		"invalid column":   "TxHash,Gas,FeePerGas,Signature,A=B\nAB,1,1,CD,1",
+		"invalid csv":      "TxHash,\"Gas\nAB,1",
+	}
+
+	for tName, input := range tests {
+		input := input
+		t.Run(tName, func(t *testing.T) {
+			_, err := CSVCodec{}.NewDecoder(strings.NewReader(input), ParseOptions{}).Decode()
+			require.ErrorIs(t, err, ErrInvalidHeader)
+			var parseErr *ParseError
+			require.False(t, errors.As(err, &parseErr))
+		})
+	}
+}
+
+func TestCSVCodec_RoundTrip(t *testing.T) {
+	input, err := os.ReadFile("../transactions.txt")
+	require.NoError(t, err)
+
+	memPool := NewMemPool(WithTieBreak(TieBreakArrival), WithCapacity(10000))
+	require.NoError(t, memPool.ReadTransactions(bytes.NewReader(input)))
+	var csvOutput bytes.Buffer
+	codec := CSVCodec{Comma: ';', Columns: []string{ColumnRank, KeyFee, KeyGas, ColumnTotalFee, KeyHash, KeySignature}}
+	require.NoError(t, memPool.WriteTransactionsWith(&csvOutput, codec))
+
+	csvPool := NewMemPool(WithTieBreak(TieBreakArrival), WithCapacity(10000))
+	report, err := csvPool.ReadTransactionsWith(&csvOutput, ReadOptions{Codec: codec})
+	require.NoError(t, err)
+	require.Equal(t, 7500, report.Accepted)
+
+	var expected, actual bytes.Buffer
+	require.NoError(t, memPool.WriteTransactions(&expected))
+	require.NoError(t, csvPool.WriteTransactions(&actual))
+	require.Equal(t, expected.String(), actual.String())
+}
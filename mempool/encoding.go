@@ -0,0 +1,64 @@
+package mempool
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// HashSize is the size of the transaction hash in bytes
+	HashSize = 32
+	// SignatureSize is the size of the transaction signature in bytes
+	SignatureSize = 64
+)
+
+var (
+	ErrInvalidHex    = errors.New("Invalid hex")
+	ErrInvalidLength = errors.New("Invalid length")
+	ErrDuplicateKey  = errors.New("Duplicate key")
+)
+
+// HashBytes returns the decoded hash of the transaction
+// The second return value is false if the hash is not a hex string of HashSize bytes
+func (t Transaction) HashBytes() ([HashSize]byte, bool) {
+	if t.decoded {
+		return t.hashBytes, true
+	}
+	var b [HashSize]byte
+	err := decodeHexField(b[:], token{key: KeyHash, value: t.Hash}, "")
+	return b, err == nil
+}
+
+// SignatureBytes returns the decoded signature of the transaction
+// The second return value is false if the signature is not a hex string of SignatureSize bytes
+func (t Transaction) SignatureBytes() ([SignatureSize]byte, bool) {
+	if t.decoded {
+		return t.signatureBytes, true
+	}
+	var b [SignatureSize]byte
+	err := decodeHexField(b[:], token{key: KeySignature, value: t.Signature}, "")
+	return b, err == nil
+}
+
+// decodeHexField decodes the hex value of the token in either case into dst, which length is the expected length of the value
+func decodeHexField(dst []byte, t token, line string) *ParseError {
+	decoded, err := hex.DecodeString(t.value)
+	if err == hex.ErrLength {
+		return t.error(ErrInvalidLength, line, fmt.Sprintf("expected %d bytes, got odd number of hex digits", len(dst)))
+	}
+	if err != nil {
+		return t.error(ErrInvalidHex, line, "")
+	}
+	if len(decoded) != len(dst) {
+		return t.error(ErrInvalidLength, line, fmt.Sprintf("expected %d bytes, got %d", len(dst), len(decoded)))
+	}
+	copy(dst, decoded)
+	return nil
+}
+
+// normalizeHash brings the hex hash to the upper case, so the hashes differing only in case identify the same transaction
+func normalizeHash(hash string) string {
+	return strings.ToUpper(hash)
+}
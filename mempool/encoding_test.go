@@ -0,0 +1,90 @@
+package mempool
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTransaction_StrictEncoding(t *testing.T) {
+	hash := "40E10C7CF56A738C0B8AD4EE30EA8008C7B2334B3ADA195083F8CB18BD3911A0"
+	signature := "6386A3893BEB6A5A64E0677F406634E791DEE78D49CF30581AE5281D4094E495E671647EF5E7FD2D207AB8EBA0EA693703E9C368402731BE99E81BDB748EA662"
+	line := func(hash, signature string) string {
+		return fmt.Sprintf("TxHash=%s Gas=729000 FeePerGas=0.11134106816568039 Signature=%s", hash, signature)
+	}
+
+	tests := map[string]struct {
+		line          string
+		expectedError error
+	}{
+		"valid upper case": {
+			line: line(hash, signature),
+		},
+		"valid lower case": {
+			line: line(strings.ToLower(hash), strings.ToLower(signature)),
+		},
+		"hash is not hex": {
+			line:          line("X"+hash[1:], signature),
+			expectedError: ErrInvalidHex,
+		},
+		"hash is too short": {
+			line:          line(hash[2:], signature),
+			expectedError: ErrInvalidLength,
+		},
+		"hash has odd length": {
+			line:          line(hash[1:], signature),
+			expectedError: ErrInvalidLength,
+		},
+		"signature is not hex": {
+			line:          line(hash, signature[:126]+"ZZ"),
+			expectedError: ErrInvalidHex,
+		},
+		"signature is too long": {
+			line:          line(hash, signature+"00"),
+			expectedError: ErrInvalidLength,
+		},
+		"duplicate key": {
+			line:          line(hash, signature) + " Gas=1",
+			expectedError: ErrDuplicateKey,
+		},
+	}
+
+	for tName, tc := range tests {
+		tc := tc
+		t.Run(tName, func(t *testing.T) {
+			tx, err := ParseTransaction(tc.line, ParseOptions{StrictEncoding: true})
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.line, tx.String())
+
+			hashBytes, ok := tx.HashBytes()
+			require.True(t, ok)
+			require.Equal(t, hash, fmt.Sprintf("%X", hashBytes))
+			signatureBytes, ok := tx.SignatureBytes()
+			require.True(t, ok)
+			require.Equal(t, signature, fmt.Sprintf("%X", signatureBytes))
+		})
+	}
+}
+
+func TestParseTransaction_DuplicateKeyIsAllowedByDefault(t *testing.T) {
+	tx, err := ParseTransaction("TxHash=AB Gas=1 FeePerGas=1 Signature=CD Gas=2", ParseOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 2, tx.Gas)
+}
+
+func TestTransaction_BytesWithoutStrictEncoding(t *testing.T) {
+	tx := Transaction{Hash: strings.Repeat("ab", HashSize), Signature: "CD"}
+
+	hashBytes, ok := tx.HashBytes()
+	require.True(t, ok)
+	require.Equal(t, strings.Repeat("AB", HashSize), fmt.Sprintf("%X", hashBytes))
+
+	_, ok = tx.SignatureBytes()
+	require.False(t, ok)
+}
@@ -0,0 +1,76 @@
+package mempool
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrFieldNotFound        = errors.New("Field not found")
+	ErrInvalidToken         = errors.New("Invalid token")
+	ErrInvalidValueForField = errors.New("Invalid value for field")
+	ErrUnknownField         = errors.New("Unknown field")
+)
+
+// ParseError describes why a transaction can't be read and where the problem is
+// Err is one of the sentinel errors of the package or an error wrapping it, so it can be matched with errors.Is
+type ParseError struct {
+	// Line is the 1-based number of the line in the input, 0 when a single line is parsed
+	Line int
+	// Column is the 1-based byte offset of the failed token in the line, 0 when the error is not related to a token
+	Column int
+	// Field is the key of the failed field, if any
+	Field string
+	// Token is the raw text of the failed token or the value of the failed field
+	Token string
+	// Text is the whole line
+	Text string
+	Err  error
+
+	// detail is appended to the message of the error
+	detail string
+}
+
+func (e *ParseError) Error() string {
+	var msg string
+	switch {
+	case e.Err == ErrFieldNotFound:
+		msg = fmt.Sprintf("Field %s not found in line [%s]", e.Field, e.Text)
+	case e.Err == ErrInvalidToken:
+		msg = fmt.Sprintf("Invalid token [%s]", e.Token)
+	case e.Err == ErrInvalidValueForField:
+		msg = fmt.Sprintf("Invalid value for field %s [%s]", e.Field, e.Token)
+	case e.Err == ErrUnknownField:
+		msg = fmt.Sprintf("Unknown field %s [%s]", e.Field, e.Token)
+	case e.Field != "":
+		msg = fmt.Sprintf("%v for field %s [%s]", e.Err, e.Field, e.Token)
+	default:
+		msg = e.Err.Error()
+	}
+	if e.detail != "" {
+		msg += ": " + e.detail
+	}
+
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		return fmt.Sprintf("Line %d, column %d: %s", e.Line, e.Column, msg)
+	case e.Line > 0:
+		return fmt.Sprintf("Line %d: %s", e.Line, msg)
+	default:
+		return msg
+	}
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// atLine sets the line number of the parse error, other errors are wrapped into a ParseError
+func atLine(err error, line int, text string) *ParseError {
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		parseErr = &ParseError{Text: text, Err: err}
+	}
+	parseErr.Line = line
+	return parseErr
+}
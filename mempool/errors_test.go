@@ -0,0 +1,129 @@
+package mempool
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadTransaction_ParseError(t *testing.T) {
+	tests := map[string]struct {
+		line          string
+		options       ParseOptions
+		expectedError ParseError
+	}{
+		"invalid token": {
+			line:          "TxHash=AB  Gas-1 FeePerGas=1 Signature=CD",
+			expectedError: ParseError{Column: 12, Token: "Gas-1", Err: ErrInvalidToken},
+		},
+		"missing field": {
+			line:          "TxHash=AB Gas=1 Signature=CD",
+			expectedError: ParseError{Field: KeyFee, Err: ErrFieldNotFound},
+		},
+		"invalid gas": {
+			line:          "TxHash=AB Gas=x FeePerGas=1 Signature=CD",
+			expectedError: ParseError{Column: 11, Field: KeyGas, Token: "x", Err: ErrInvalidValueForField},
+		},
+		"invalid fee after multibyte whitespace": {
+			line:          "TxHash=AB\u00a0Gas=1 FeePerGas=y Signature=CD",
+			expectedError: ParseError{Column: 18, Field: KeyFee, Token: "y", Err: ErrInvalidValueForField},
+		},
+		"duplicate key": {
+			line:          "TxHash=AB Gas=1 FeePerGas=1 Signature=CD Gas=2",
+			options:       ParseOptions{StrictEncoding: true},
+			expectedError: ParseError{Column: 42, Field: KeyGas, Token: "2", Err: ErrDuplicateKey},
+		},
+		"invalid hex": {
+			line:          "Gas=1 FeePerGas=1 Signature=CD TxHash=XY",
+			options:       ParseOptions{StrictEncoding: true},
+			expectedError: ParseError{Column: 32, Field: KeyHash, Token: "XY", Err: ErrInvalidHex},
+		},
+		"invalid length": {
+			line:          "TxHash=AB Gas=1 FeePerGas=1 Signature=CD",
+			options:       ParseOptions{StrictEncoding: true},
+			expectedError: ParseError{Column: 1, Field: KeyHash, Token: "AB", Err: ErrInvalidLength},
+		},
+		"hash mismatch": {
+			line:          "TxHash=AB Gas=1 FeePerGas=1 Signature=CD",
+			options:       ParseOptions{VerifyHash: true},
+			expectedError: ParseError{Column: 1, Field: KeyHash, Token: "AB", Err: ErrHashMismatch},
+		},
+		"unknown field": {
+			line:          "TxHash=AB Gas=1 Memo=x FeePerGas=1 Signature=CD Chain=2",
+			options:       ParseOptions{RejectUnknownFields: true},
+			expectedError: ParseError{Column: 17, Field: "Memo", Token: "x", Err: ErrUnknownField},
+		},
+		"empty key": {
+			line:          "TxHash=AB Gas=1 FeePerGas=1 Signature=CD =x",
+			expectedError: ParseError{Column: 42, Err: ErrInvalidToken},
+		},
+	}
+
+	for tName, tc := range tests {
+		tc := tc
+		t.Run(tName, func(t *testing.T) {
+			_, err := ParseTransaction(tc.line, tc.options)
+			require.ErrorIs(t, err, tc.expectedError.Err)
+
+			var parseErr *ParseError
+			require.True(t, errors.As(err, &parseErr))
+			require.Equal(t, 0, parseErr.Line)
+			require.Equal(t, tc.expectedError.Column, parseErr.Column)
+			require.Equal(t, tc.expectedError.Field, parseErr.Field)
+			require.Equal(t, tc.expectedError.Token, parseErr.Token)
+			require.Equal(t, tc.line, parseErr.Text)
+		})
+	}
+}
+
+func TestParseError_Error(t *testing.T) {
+	tests := map[string]struct {
+		err      ParseError
+		expected string
+	}{
+		"field not found": {
+			err:      ParseError{Field: KeyGas, Text: "TxHash=AB", Err: ErrFieldNotFound},
+			expected: "Field Gas not found in line [TxHash=AB]",
+		},
+		"invalid token with position": {
+			err:      ParseError{Line: 3, Column: 5, Token: "abc", Err: ErrInvalidToken},
+			expected: "Line 3, column 5: Invalid token [abc]",
+		},
+		"invalid length with detail": {
+			err:      ParseError{Line: 2, Column: 1, Field: KeyHash, Token: "AB", Err: ErrInvalidLength, detail: "expected 32 bytes, got 1"},
+			expected: "Line 2, column 1: Invalid length for field TxHash [AB]: expected 32 bytes, got 1",
+		},
+		"unknown field": {
+			err:      ParseError{Line: 1, Column: 17, Field: "Memo", Token: "x", Err: ErrUnknownField},
+			expected: "Line 1, column 17: Unknown field Memo [x]",
+		},
+		"other error": {
+			err:      ParseError{Line: 7, Err: ErrInvalidSignature},
+			expected: "Line 7: Invalid signature",
+		},
+	}
+
+	for tName, tc := range tests {
+		tc := tc
+		t.Run(tName, func(t *testing.T) {
+			require.EqualError(t, &tc.err, tc.expected)
+		})
+	}
+}
+
+func TestMemPool_ReadTransactionsParseErrorLine(t *testing.T) {
+	input := "TxHash=AB Gas=1 FeePerGas=1 Signature=CD\r\n\nTxHash=CD Gas=1 FeePerGas=z Signature=CD\r\n"
+
+	err := NewMemPool().ReadTransactions(strings.NewReader(input))
+	var parseErr *ParseError
+	require.True(t, errors.As(err, &parseErr))
+	require.ErrorIs(t, err, ErrInvalidValueForField)
+	require.Equal(t, 3, parseErr.Line)
+	require.Equal(t, 17, parseErr.Column)
+	require.Equal(t, KeyFee, parseErr.Field)
+	require.Equal(t, "z", parseErr.Token)
+	require.Equal(t, "TxHash=CD Gas=1 FeePerGas=z Signature=CD", parseErr.Text)
+	require.EqualError(t, err, "Line 3, column 17: Invalid value for field FeePerGas [z]")
+}
@@ -0,0 +1,209 @@
+package mempool
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+var ErrUnreachableFee = errors.New("Fee target can't be reached")
+
+// feeEstimateDigits is the number of the fractional digits of the estimated fees per gas
+const feeEstimateDigits = 18
+
+// defaultPercentiles are the percentiles of the fees per gas of the pool reported by default
+var defaultPercentiles = []int{10, 25, 50, 75, 90}
+
+// EstimateOptions are the targets of MemPool.EstimateFee
+type EstimateOptions struct {
+	// Gas is the gas of the transaction the fee is estimated for
+	Gas int
+	// Blocks is the number of the blocks with GasLimit the transaction should be included within, 0 disables the target
+	Blocks   int
+	GasLimit int
+	// Top is the number of the transactions with the highest priority the transaction should be among,
+	// 0 disables the target
+	Top int
+	// Percentiles are the percentiles of the fees per gas of the pool to report, 10, 25, 50, 75 and 90 if it is nil
+	Percentiles []int
+}
+
+// FeePercentile is the fee per gas which the given percent of the transactions of the pool don't exceed
+type FeePercentile struct {
+	Percent   int
+	FeePerGas *big.Rat
+}
+
+// FeeEstimate is the fee per gas a transaction needs to meet the targets
+// The fee per gas is the one of a transaction with a flat fee, a transaction with the dynamic fee fields needs
+// the max fee per gas of at least the same and the max priority fee per gas of at least the same minus the base fee
+type FeeEstimate struct {
+	// FeePerGas is the recommended fee per gas, the lowest one meeting all the targets
+	FeePerGas *big.Rat
+	// InclusionFeePerGas is the lowest fee per gas to be included within the blocks, nil if the target is disabled
+	InclusionFeePerGas *big.Rat
+	// TopFeePerGas is the lowest fee per gas to be among the top transactions, nil if the target is disabled
+	TopFeePerGas *big.Rat
+	// MinFeePerGas is the lowest fee per gas to stay in the pool, it exceeds the base fee only if the pool is full
+	MinFeePerGas *big.Rat
+	// Percentiles are the effective fees per gas of the transactions of the pool, none if the pool is empty
+	Percentiles []FeePercentile
+}
+
+// EstimateFee returns the fees per gas a transaction with the given gas needs to meet the targets based on the
+// current transactions of the pool. The fees are the lowest decimals with up to 18 fractional digits, which outrank
+// the transactions the new one has to pass, as it is placed after the ones with the equal priority.
+// The inclusion within the blocks is estimated by building them greedily without new arrivals.
+// It returns ErrUnreachableFee if the gas exceeds the gas limit of the blocks
+func (m *MemPool) EstimateFee(options EstimateOptions) (FeeEstimate, error) {
+	if options.Gas <= 0 {
+		return FeeEstimate{}, fmt.Errorf("%w %s [%d]", ErrInvalidValueForField, KeyGas, options.Gas)
+	}
+	if options.Blocks > 0 && options.Gas > options.GasLimit {
+		return FeeEstimate{}, fmt.Errorf("%w, the gas [%d] exceeds the gas limit [%d]", ErrUnreachableFee, options.Gas, options.GasLimit)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	estimate := FeeEstimate{MinFeePerGas: m.minFeePerGas(options.Gas)}
+	estimate.FeePerGas = estimate.MinFeePerGas
+	if options.Blocks > 0 {
+		estimate.InclusionFeePerGas = m.inclusionFeePerGas(options.Gas, options.Blocks, options.GasLimit)
+		estimate.FeePerGas = maxRat(estimate.FeePerGas, estimate.InclusionFeePerGas)
+	}
+	if options.Top > 0 {
+		estimate.TopFeePerGas = m.topFeePerGas(options.Gas, options.Top)
+		estimate.FeePerGas = maxRat(estimate.FeePerGas, estimate.TopFeePerGas)
+	}
+
+	percents := options.Percentiles
+	if percents == nil {
+		percents = defaultPercentiles
+	}
+	estimate.Percentiles = m.feePercentiles(percents)
+	return estimate, nil
+}
+
+// minFeePerGas returns the lowest fee per gas which doesn't make the transaction parked or evicted right away
+// When the pool is full, the parked and the queued transactions are evicted before a poppable one
+func (m *MemPool) minFeePerGas(gas int) *big.Rat {
+	lowest, ok := m.queue.PeekLowest()
+	if m.len() < m.capacity || m.parked.Len() > 0 || m.queued.Len() > 0 || !ok {
+		return m.feeForPriority(new(big.Rat), gas, false)
+	}
+	return maxRat(m.feeForPriority(lowest.priority, gas, true), m.feeForPriority(new(big.Rat), gas, false))
+}
+
+// inclusionFeePerGas returns the lowest fee per gas to be included within the blocks
+// A transaction which is not included doesn't change the blocks, so the blocks are built without it. In every block
+// it is popped before the first selected transaction it outranks, which must leave room for its gas. The selected
+// transactions are taken in the order they are popped, as in the nonce ordering mode a follower with a high priority
+// is reachable only after the preceding transaction of its sender
+func (m *MemPool) inclusionFeePerGas(gas int, blocks int, gasLimit int) *big.Rat {
+	sim := m.clone()
+	var lowest *big.Rat
+	for block := 0; block < blocks; block++ {
+		entries := sim.packGreedy(gasLimit)
+		for _, e := range entries {
+			sim.queue.Remove(entryKey(e))
+			sim.popped(e)
+		}
+
+		// outranked is the lowest priority of the transactions the new one can be popped before
+		var outranked *big.Rat
+		used := 0
+		for _, e := range entries {
+			if used > gasLimit-gas {
+				break
+			}
+			if outranked == nil || e.priority.Cmp(outranked) < 0 {
+				outranked = e.priority
+			}
+			used += e.tx.Gas
+		}
+		fee := m.feeForPriority(new(big.Rat), gas, false)
+		if used > gasLimit-gas {
+			fee = m.feeForPriority(outranked, gas, true)
+		}
+		if lowest == nil || fee.Cmp(lowest) < 0 {
+			lowest = fee
+		}
+	}
+	return lowest
+}
+
+// topFeePerGas returns the lowest fee per gas to be among the top transactions of the queue
+func (m *MemPool) topFeePerGas(gas int, top int) *big.Rat {
+	fee := m.feeForPriority(new(big.Rat), gas, false)
+	rank := 0
+	m.queue.Descend(func(e poolEntry) bool {
+		rank++
+		if rank < top {
+			return true
+		}
+		fee = m.feeForPriority(e.priority, gas, true)
+		return false
+	})
+	return fee
+}
+
+// feePercentiles returns the effective fees per gas of the transactions of the pool at the percentiles by the
+// nearest rank, the transactions without gas are not taken into account
+func (m *MemPool) feePercentiles(percents []int) []FeePercentile {
+	var fees []*big.Rat
+	m.forEach(func(e poolEntry) {
+		if e.tx.Gas > 0 {
+			fees = append(fees, m.effectiveFeePerGas(e.priority, e.tx.Gas))
+		}
+	})
+	if len(fees) == 0 {
+		return nil
+	}
+	sort.Slice(fees, func(i, j int) bool {
+		return fees[i].Cmp(fees[j]) < 0
+	})
+
+	percentiles := make([]FeePercentile, 0, len(percents))
+	for _, percent := range percents {
+		rank := (percent*len(fees) + 99) / 100
+		if rank < 1 {
+			rank = 1
+		}
+		if rank > len(fees) {
+			rank = len(fees)
+		}
+		percentiles = append(percentiles, FeePercentile{Percent: percent, FeePerGas: fees[rank-1]})
+	}
+	return percentiles
+}
+
+// effectiveFeePerGas returns the fee per gas of a transaction with a flat fee which has the priority
+func (m *MemPool) effectiveFeePerGas(priority *big.Rat, gas int) *big.Rat {
+	fee := new(big.Rat).Quo(priority, new(big.Rat).SetInt64(int64(gas)))
+	if m.baseFee != nil {
+		fee.Add(fee, m.baseFee)
+	}
+	return fee
+}
+
+// feeForPriority returns the lowest fee per gas with feeEstimateDigits fractional digits which gives the transaction
+// the priority, or a higher one if strict
+func (m *MemPool) feeForPriority(priority *big.Rat, gas int, strict bool) *big.Rat {
+	fee := m.effectiveFeePerGas(priority, gas)
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(feeEstimateDigits), nil)
+	scaled, rem := new(big.Int).QuoRem(new(big.Int).Mul(fee.Num(), scale), fee.Denom(), new(big.Int))
+	// The quotient is truncated towards zero, so it is rounded up only for a positive remainder
+	if rem.Sign() > 0 || (strict && rem.Sign() == 0) {
+		scaled.Add(scaled, big.NewInt(1))
+	}
+	return new(big.Rat).SetFrac(scaled, scale)
+}
+
+func maxRat(a, b *big.Rat) *big.Rat {
+	if a.Cmp(b) < 0 {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,177 @@
+package mempool
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func ratString(r *big.Rat) string {
+	if r == nil {
+		return ""
+	}
+	return r.RatString()
+}
+
+func TestMemPool_EstimateFee(t *testing.T) {
+	txs := []Transaction{
+		{Hash: "a", Gas: 5, FeePerGas: "4", Signature: "CD"},
+		{Hash: "b", Gas: 5, FeePerGas: "3", Signature: "CD"},
+		{Hash: "c", Gas: 5, FeePerGas: "2", Signature: "CD"},
+		{Hash: "d", Gas: 5, FeePerGas: "1", Signature: "CD"},
+		{Hash: "e", Gas: 20, FeePerGas: "9", Signature: "CD"},
+	}
+	tests := map[string]struct {
+		options           []Option
+		estimate          EstimateOptions
+		expectedFee       string
+		expectedInclusion string
+		expectedTop       string
+		expectedMin       string
+	}{
+		"next block": {
+			estimate:          EstimateOptions{Gas: 5, Blocks: 1, GasLimit: 10},
+			expectedFee:       "3.000000000000000001",
+			expectedInclusion: "3.000000000000000001",
+			expectedMin:       "0",
+		},
+		"within two blocks": {
+			estimate:          EstimateOptions{Gas: 5, Blocks: 2, GasLimit: 10},
+			expectedFee:       "1.000000000000000001",
+			expectedInclusion: "1.000000000000000001",
+			expectedMin:       "0",
+		},
+		"room left within the blocks": {
+			estimate:          EstimateOptions{Gas: 5, Blocks: 3, GasLimit: 10},
+			expectedFee:       "0",
+			expectedInclusion: "0",
+			expectedMin:       "0",
+		},
+		"top transactions": {
+			estimate:    EstimateOptions{Gas: 5, Top: 2},
+			expectedFee: "4.000000000000000001",
+			expectedTop: "4.000000000000000001",
+			expectedMin: "0",
+		},
+		"top of a short queue": {
+			estimate:    EstimateOptions{Gas: 5, Top: 10},
+			expectedFee: "0",
+			expectedTop: "0",
+			expectedMin: "0",
+		},
+		"rounded up": {
+			estimate:    EstimateOptions{Gas: 7, Top: 1},
+			expectedFee: "25.714285714285714286",
+			expectedTop: "25.714285714285714286",
+			expectedMin: "0",
+		},
+		"full pool": {
+			options:           []Option{WithCapacity(5)},
+			estimate:          EstimateOptions{Gas: 5, Blocks: 3, GasLimit: 10},
+			expectedFee:       "1.000000000000000001",
+			expectedInclusion: "0",
+			expectedMin:       "1.000000000000000001",
+		},
+		"base fee": {
+			options:           []Option{WithBaseFee(big.NewRat(1, 1))},
+			estimate:          EstimateOptions{Gas: 5, Blocks: 1, GasLimit: 10, Top: 1},
+			expectedFee:       "33.000000000000000001",
+			expectedInclusion: "3.000000000000000001",
+			expectedTop:       "33.000000000000000001",
+			expectedMin:       "1",
+		},
+	}
+
+	for tName, tc := range tests {
+		tc := tc
+		t.Run(tName, func(t *testing.T) {
+			memPool := NewMemPool(tc.options...)
+			for _, tx := range txs {
+				mustPush(t, memPool, tx)
+			}
+
+			estimate, err := memPool.EstimateFee(tc.estimate)
+			require.NoError(t, err)
+			require.Equal(t, testRat(tc.expectedFee).RatString(), ratString(estimate.FeePerGas))
+			require.Equal(t, testRat(tc.expectedMin).RatString(), ratString(estimate.MinFeePerGas))
+			if tc.expectedInclusion == "" {
+				require.Nil(t, estimate.InclusionFeePerGas)
+			} else {
+				require.Equal(t, testRat(tc.expectedInclusion).RatString(), ratString(estimate.InclusionFeePerGas))
+			}
+			if tc.expectedTop == "" {
+				require.Nil(t, estimate.TopFeePerGas)
+			} else {
+				require.Equal(t, testRat(tc.expectedTop).RatString(), ratString(estimate.TopFeePerGas))
+			}
+			require.Equal(t, 5, memPool.Len())
+		})
+	}
+}
+
+func TestMemPool_EstimateFeeNonceOrdering(t *testing.T) {
+	memPool := NewMemPool(WithNonceOrdering())
+	mustPush(t, memPool, Transaction{Hash: "alice0", Gas: 1, FeePerGas: "1", Signature: "CD", Sender: "alice", Nonce: 0, HasNonce: true})
+	mustPush(t, memPool, Transaction{Hash: "alice1", Gas: 10, FeePerGas: "9", Signature: "CD", Sender: "alice", Nonce: 1, HasNonce: true})
+	mustPush(t, memPool, Transaction{Hash: "bob", Gas: 5, FeePerGas: "4", Signature: "CD"})
+
+	// The new transaction has to outrank only the first transaction of alice, as her second one with the higher
+	// priority is popped after it
+	estimate, err := memPool.EstimateFee(EstimateOptions{Gas: 5, Blocks: 1, GasLimit: 16})
+	require.NoError(t, err)
+	require.Equal(t, testRat("0.200000000000000001").RatString(), ratString(estimate.InclusionFeePerGas))
+
+	tx := Transaction{Hash: "new", Gas: 5, FeePerGas: estimate.FeePerGas.FloatString(18), Signature: "CD"}
+	inclusions := memPool.Forecast(ForecastOptions{GasLimit: 16, Blocks: 1, ArrivalsPerBlock: 1, Arrivals: []Transaction{tx}})
+	require.Equal(t, map[string]int{
+		"alice0@1": 1, "alice1@9": 0, "bob@4": 1, "new@" + tx.FeePerGas: 1,
+	}, inclusionBlocks(inclusions))
+}
+
+func TestMemPool_EstimateFeeIncluded(t *testing.T) {
+	memPool := readTransactionsFile(t)
+	estimate, err := memPool.EstimateFee(EstimateOptions{Gas: 100000, Blocks: 1, GasLimit: 3000000})
+	require.NoError(t, err)
+
+	// A transaction paying the estimated fee is included in the next block and one paying the percentile is not
+	tx := Transaction{Hash: "estimated", Gas: 100000, FeePerGas: estimate.FeePerGas.FloatString(18), Signature: "CD"}
+	inclusions := memPool.Forecast(ForecastOptions{GasLimit: 3000000, Blocks: 1, ArrivalsPerBlock: 1, Arrivals: []Transaction{tx}})
+	require.Equal(t, 1, inclusions[len(inclusions)-1].Block)
+
+	tx.FeePerGas = estimate.Percentiles[0].FeePerGas.FloatString(18)
+	inclusions = memPool.Forecast(ForecastOptions{GasLimit: 3000000, Blocks: 1, ArrivalsPerBlock: 1, Arrivals: []Transaction{tx}})
+	require.Equal(t, 0, inclusions[len(inclusions)-1].Block)
+}
+
+func TestMemPool_EstimateFeePercentiles(t *testing.T) {
+	memPool := NewMemPool()
+	for _, fee := range []string{"4", "3", "2", "1", "9"} {
+		mustPush(t, memPool, Transaction{Hash: fee, Gas: 5, FeePerGas: fee, Signature: "CD"})
+	}
+	mustPush(t, memPool, Transaction{Hash: "free", Gas: 0, FeePerGas: "100", Signature: "CD"})
+
+	estimate, err := memPool.EstimateFee(EstimateOptions{Gas: 1, Percentiles: []int{0, 10, 25, 50, 75, 90, 100}})
+	require.NoError(t, err)
+	percentiles := make(map[int]string)
+	for _, p := range estimate.Percentiles {
+		percentiles[p.Percent] = p.FeePerGas.RatString()
+	}
+	require.Equal(t, map[int]string{0: "1", 10: "1", 25: "2", 50: "3", 75: "4", 90: "9", 100: "9"}, percentiles)
+
+	estimate, err = memPool.EstimateFee(EstimateOptions{Gas: 1})
+	require.NoError(t, err)
+	require.Len(t, estimate.Percentiles, len(defaultPercentiles))
+
+	estimate, err = NewMemPool().EstimateFee(EstimateOptions{Gas: 1})
+	require.NoError(t, err)
+	require.Empty(t, estimate.Percentiles)
+}
+
+func TestMemPool_EstimateFeeErrors(t *testing.T) {
+	memPool := NewMemPool()
+	_, err := memPool.EstimateFee(EstimateOptions{Gas: 0, Top: 1})
+	require.ErrorIs(t, err, ErrInvalidValueForField)
+	_, err = memPool.EstimateFee(EstimateOptions{Gas: 11, Blocks: 1, GasLimit: 10})
+	require.ErrorIs(t, err, ErrUnreachableFee)
+}
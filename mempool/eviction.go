@@ -0,0 +1,40 @@
+package mempool
+
+// EvictionReason tells why a transaction was dropped from the pool
+type EvictionReason int
+
+const (
+	// EvictionCapacity means that the pool was full and the transaction had the lowest priority
+	EvictionCapacity EvictionReason = iota + 1
+	// EvictionExpired means that the transaction stayed in the pool for too long
+	EvictionExpired
+	// EvictionReplaced means that the transaction was replaced by another one with the same hash
+	EvictionReplaced
+	// EvictionRemoved means that the transaction was removed explicitly, e.g. because it has been mined
+	EvictionRemoved
+	// EvictionStale means that a transaction of the sender with the same or a higher nonce was already popped
+	EvictionStale
+)
+
+func (r EvictionReason) String() string {
+	switch r {
+	case EvictionCapacity:
+		return "capacity"
+	case EvictionExpired:
+		return "expired"
+	case EvictionReplaced:
+		return "replaced"
+	case EvictionRemoved:
+		return "removed"
+	case EvictionStale:
+		return "stale"
+	default:
+		return "unknown"
+	}
+}
+
+// Eviction is reported to the eviction hook of the pool for every dropped transaction
+type Eviction struct {
+	Transaction Transaction
+	Reason      EvictionReason
+}
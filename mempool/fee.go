@@ -0,0 +1,80 @@
+package mempool
+
+import (
+	"math/big"
+	"strings"
+)
+
+// maxDecimalDigits limits the number of fractional digits when formatting a number without a finite decimal representation
+const maxDecimalDigits = 36
+
+// parseDecimal parses the exact value of a decimal number, e.g. 0.11134106816568039 or 9.556431783046658e-05
+// The second return value is false if the string is not a decimal number
+func parseDecimal(s string) (*big.Rat, bool) {
+	// big.Rat also accepts fractions like 1/3, which are not valid decimal numbers
+	if strings.Contains(s, "/") {
+		return nil, false
+	}
+	return new(big.Rat).SetString(s)
+}
+
+// formatDecimal formats the exact value of the number without an exponent and trailing zeros, e.g. 0.00009556 for 9.556e-05
+// A number without a finite decimal representation, like 1/3, is rounded to maxDecimalDigits fractional digits
+func formatDecimal(r *big.Rat) string {
+	digits := 0
+	scale := big.NewInt(1)
+	ten := big.NewInt(10)
+	rem := new(big.Int)
+	for digits < maxDecimalDigits && rem.Mod(scale, r.Denom()).Sign() != 0 {
+		scale.Mul(scale, ten)
+		digits++
+	}
+	return r.FloatString(digits)
+}
+
+// IsDynamicFee reports whether the transaction has the dynamic fee fields, MaxFeePerGas and MaxPriorityFeePerGas
+func (t Transaction) IsDynamicFee() bool {
+	return t.MaxFeePerGas != "" || t.MaxPriorityFeePerGas != ""
+}
+
+// hasFeePerGas reports whether FeePerGas is written, it can be omitted only if the transaction has a dynamic fee
+func (t Transaction) hasFeePerGas() bool {
+	return t.FeePerGas != "" || !t.IsDynamicFee()
+}
+
+// EffectiveTip returns the fee per gas the transaction pays on top of the base fee, i.e.
+// min(MaxPriorityFeePerGas, MaxFeePerGas - baseFee), or FeePerGas - baseFee if the transaction has a flat fee.
+// The second return value is false if the max fee per gas is below the base fee, so the transaction can't be included.
+// A nil base fee is the same as zero
+func (t Transaction) EffectiveTip(baseFee *big.Rat) (*big.Rat, bool) {
+	maxFee, maxTip := t.feeCaps()
+	tip := new(big.Rat).Set(maxFee)
+	if baseFee != nil {
+		tip.Sub(tip, baseFee)
+	}
+	if tip.Cmp(maxTip) > 0 {
+		tip.Set(maxTip)
+	}
+	return tip, baseFee == nil || maxFee.Cmp(baseFee) >= 0
+}
+
+// feeCaps returns the max fee per gas and the max tip per gas of the transaction, which must not be modified
+// A flat fee per gas is both of them
+func (t Transaction) feeCaps() (*big.Rat, *big.Rat) {
+	if !t.IsDynamicFee() {
+		fee := decimalValue(t.FeePerGas, t.feePerGasNumeric)
+		return fee, fee
+	}
+	return decimalValue(t.MaxFeePerGas, t.maxFeeNumeric), decimalValue(t.MaxPriorityFeePerGas, t.maxPriorityFeeNumeric)
+}
+
+// decimalValue returns the parsed value of the decimal or parses it, an invalid decimal is zero
+func decimalValue(s string, parsed *big.Rat) *big.Rat {
+	if parsed != nil {
+		return parsed
+	}
+	if value, ok := parseDecimal(s); ok {
+		return value
+	}
+	return new(big.Rat)
+}
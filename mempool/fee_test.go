@@ -0,0 +1,86 @@
+package mempool
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected *big.Rat
+	}{
+		"integer":  {input: "12", expected: big.NewRat(12, 1)},
+		"decimal":  {input: "0.25", expected: big.NewRat(1, 4)},
+		"exponent": {input: "2.5e-03", expected: big.NewRat(1, 400)},
+		"fraction": {input: "1/4"},
+		"text":     {input: "abc"},
+		"empty":    {input: ""},
+	}
+
+	for tName, tc := range tests {
+		tc := tc
+		t.Run(tName, func(t *testing.T) {
+			r, ok := parseDecimal(tc.input)
+			if tc.expected == nil {
+				require.False(t, ok)
+			} else {
+				require.True(t, ok)
+				require.Zero(t, tc.expected.Cmp(r))
+			}
+		})
+	}
+}
+
+func TestFormatDecimal(t *testing.T) {
+	tests := map[string]struct {
+		input    *big.Rat
+		expected string
+	}{
+		"integer":       {input: big.NewRat(12, 1), expected: "12"},
+		"zero":          {input: new(big.Rat), expected: "0"},
+		"decimal":       {input: big.NewRat(1, 4), expected: "0.25"},
+		"small":         {input: testRat("9.556431783046658e-05"), expected: "0.00009556431783046658"},
+		"negative":      {input: big.NewRat(-5, 2), expected: "-2.5"},
+		"long fraction": {input: testRat("0.100000000000000000001"), expected: "0.100000000000000000001"},
+		"periodic":      {input: big.NewRat(1, 3), expected: "0.333333333333333333333333333333333333"},
+	}
+
+	for tName, tc := range tests {
+		tc := tc
+		t.Run(tName, func(t *testing.T) {
+			require.Equal(t, tc.expected, formatDecimal(tc.input))
+		})
+	}
+}
+
+func TestTransaction_EffectiveTip(t *testing.T) {
+	dynamic := Transaction{Hash: "AB", Gas: 1, Signature: "CD", MaxFeePerGas: "10", MaxPriorityFeePerGas: "2"}
+	flat := Transaction{Hash: "AB", Gas: 1, FeePerGas: "5", Signature: "CD"}
+	tests := map[string]struct {
+		tx                 Transaction
+		baseFee            *big.Rat
+		expectedTip        *big.Rat
+		expectedIncludable bool
+	}{
+		"without base fee":           {tx: dynamic, expectedTip: big.NewRat(2, 1), expectedIncludable: true},
+		"capped by max priority fee": {tx: dynamic, baseFee: big.NewRat(7, 1), expectedTip: big.NewRat(2, 1), expectedIncludable: true},
+		"capped by max fee":          {tx: dynamic, baseFee: big.NewRat(17, 2), expectedTip: big.NewRat(3, 2), expectedIncludable: true},
+		"max fee equals base fee":    {tx: dynamic, baseFee: big.NewRat(10, 1), expectedTip: new(big.Rat), expectedIncludable: true},
+		"max fee below base fee":     {tx: dynamic, baseFee: big.NewRat(11, 1), expectedTip: big.NewRat(-1, 1)},
+		"flat fee":                   {tx: flat, baseFee: big.NewRat(3, 1), expectedTip: big.NewRat(2, 1), expectedIncludable: true},
+		"flat fee below base fee":    {tx: flat, baseFee: big.NewRat(6, 1), expectedTip: big.NewRat(-1, 1)},
+		"flat fee without base fee":  {tx: flat, expectedTip: big.NewRat(5, 1), expectedIncludable: true},
+	}
+
+	for tName, tc := range tests {
+		tc := tc
+		t.Run(tName, func(t *testing.T) {
+			tip, includable := tc.tx.EffectiveTip(tc.baseFee)
+			require.Zero(t, tc.expectedTip.Cmp(tip), "expected %s, got %s", tc.expectedTip.RatString(), tip.RatString())
+			require.Equal(t, tc.expectedIncludable, includable)
+		})
+	}
+}
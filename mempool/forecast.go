@@ -0,0 +1,121 @@
+package mempool
+
+// ForecastOptions configure the simulation of MemPool.Forecast
+type ForecastOptions struct {
+	// GasLimit is the gas limit of every block
+	GasLimit int
+	// Blocks is the number of the simulated blocks
+	Blocks int
+	// Strategy is the packing strategy of the blocks
+	Strategy PackingStrategy
+	// Arrivals are the transactions arriving at the pool while the blocks are built, ArrivalsPerBlock of them
+	// in their order before every block
+	Arrivals         []Transaction
+	ArrivalsPerBlock int
+}
+
+// Inclusion is the projected block of a transaction
+type Inclusion struct {
+	Transaction Transaction
+	// Block is the number of the block the transaction is included in, starting from 1 for the next block,
+	// or 0 if it is not included within the simulated blocks
+	Block int
+}
+
+// Forecast simulates the consecutive blocks built from the pool and returns the projected blocks of its transactions
+// followed by the ones of the arrived transactions. The transactions of the pool are in the order they would be
+// written and the arrived ones in the order of their arrival, the arrivals after the last block are not reported.
+// The arrived transactions are added as with Push, so they may replace or evict the other ones, which are not included
+// then. The included transactions leave the pool as if they were popped.
+// The pool is not modified
+func (m *MemPool) Forecast(options ForecastOptions) []Inclusion {
+	m.mu.Lock()
+	sim := m.clone()
+	var entries []poolEntry
+	m.descend(func(e poolEntry) bool {
+		entries = append(entries, e)
+		return true
+	})
+	m.mu.Unlock()
+
+	// included are the numbers of the blocks by the sequence numbers of the entries
+	included := make(map[uint64]int)
+	arrivals := options.Arrivals
+	for block := 1; block <= options.Blocks; block++ {
+		n := options.ArrivalsPerBlock
+		if n > len(arrivals) {
+			n = len(arrivals)
+		}
+		if n < 0 {
+			n = 0
+		}
+		now := sim.now()
+		for _, tx := range arrivals[:n] {
+			e := sim.newEntry(tx, now)
+			entries = append(entries, e)
+			if sim.checkReplacement(tx, nil) == nil {
+				sim.pushEntry(e)
+			}
+		}
+		arrivals = arrivals[n:]
+
+		for _, e := range sim.pack(options.GasLimit, options.Strategy) {
+			included[e.seq] = block
+			sim.queue.Remove(entryKey(e))
+			sim.popped(e)
+		}
+	}
+
+	inclusions := make([]Inclusion, 0, len(entries))
+	for _, e := range entries {
+		inclusions = append(inclusions, Inclusion{Transaction: e.tx, Block: included[e.seq]})
+	}
+	return inclusions
+}
+
+// clone copies the pool with its settings and transactions, it must be called holding the lock
+// The copy has no eviction hook, no signature verifier and an empty quarantine
+func (m *MemPool) clone() *MemPool {
+	c := &MemPool{
+		pushed:        make(chan struct{}),
+		now:           m.now,
+		seq:           m.seq,
+		capacity:      m.capacity,
+		tieBreak:      m.tieBreak,
+		parseOptions:  m.parseOptions,
+		nonceOrdering: m.nonceOrdering,
+		accounts:      make(map[string]*account, len(m.accounts)),
+		nonces:        make(map[string]senderNonce, len(m.nonces)),
+		heads:         m.heads,
+		replaceByFee:  m.replaceByFee,
+		replaceBump:   m.replaceBump,
+		baseFee:       m.baseFee,
+		senderNonces:  make(map[senderNonce]string, len(m.senderNonces)),
+	}
+	c.queue, c.parked, c.queued = c.copyQueue(m.queue), c.copyQueue(m.parked), c.copyQueue(m.queued)
+	for sender, a := range m.accounts {
+		txs := make(map[uint64]poolEntry, len(a.txs))
+		for nonce, e := range a.txs {
+			txs[nonce] = e
+		}
+		c.accounts[sender] = &account{next: a.next, nextKnown: a.nextKnown, txs: txs, head: a.head}
+	}
+	for key, sn := range m.nonces {
+		c.nonces[key] = sn
+	}
+	for sn, key := range m.senderNonces {
+		c.senderNonces[sn] = key
+	}
+	return c
+}
+
+func (m *MemPool) copyQueue(q *KeyedPriorityQueue[string, poolEntry]) *KeyedPriorityQueue[string, poolEntry] {
+	entries := make([]poolEntry, 0, q.Len())
+	q.Ascend(func(e poolEntry) bool {
+		entries = append(entries, e)
+		return true
+	})
+	c := m.newQueue()
+	c.PushBatch(entries)
+	return c
+}
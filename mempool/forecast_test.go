@@ -0,0 +1,101 @@
+package mempool
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func inclusionBlocks(inclusions []Inclusion) map[string]int {
+	blocks := make(map[string]int)
+	for _, inclusion := range inclusions {
+		blocks[inclusion.Transaction.Hash+"@"+inclusion.Transaction.FeePerGas] = inclusion.Block
+	}
+	return blocks
+}
+
+func TestMemPool_Forecast(t *testing.T) {
+	txs := []Transaction{
+		{Hash: "a", Gas: 5, FeePerGas: "4", Signature: "CD"},
+		{Hash: "b", Gas: 5, FeePerGas: "3", Signature: "CD"},
+		{Hash: "c", Gas: 5, FeePerGas: "2", Signature: "CD"},
+		{Hash: "d", Gas: 5, FeePerGas: "1", Signature: "CD"},
+		{Hash: "e", Gas: 20, FeePerGas: "9", Signature: "CD"},
+	}
+	tests := map[string]struct {
+		options        []Option
+		forecast       ForecastOptions
+		expectedBlocks map[string]int
+	}{
+		"without arrivals": {
+			forecast:       ForecastOptions{GasLimit: 10, Blocks: 1},
+			expectedBlocks: map[string]int{"a@4": 1, "b@3": 1, "c@2": 0, "d@1": 0, "e@9": 0},
+		},
+		"consecutive blocks": {
+			forecast:       ForecastOptions{GasLimit: 10, Blocks: 3},
+			expectedBlocks: map[string]int{"a@4": 1, "b@3": 1, "c@2": 2, "d@1": 2, "e@9": 0},
+		},
+		"arrivals": {
+			forecast: ForecastOptions{GasLimit: 10, Blocks: 2, ArrivalsPerBlock: 2, Arrivals: []Transaction{
+				{Hash: "f", Gas: 5, FeePerGas: "3.5", Signature: "CD"},
+				{Hash: "g", Gas: 5, FeePerGas: "0.5", Signature: "CD"},
+				{Hash: "c", Gas: 5, FeePerGas: "10", Signature: "CD"},
+				{Hash: "h", Gas: 5, FeePerGas: "5", Signature: "CD"},
+				{Hash: "i", Gas: 5, FeePerGas: "6", Signature: "CD"},
+			}},
+			expectedBlocks: map[string]int{
+				"a@4": 1, "b@3": 0, "c@2": 0, "d@1": 0, "e@9": 0,
+				"f@3.5": 1, "g@0.5": 0, "c@10": 2, "h@5": 2,
+			},
+		},
+		"capacity": {
+			options: []Option{WithCapacity(5)},
+			forecast: ForecastOptions{GasLimit: 10, Blocks: 1, ArrivalsPerBlock: 1, Arrivals: []Transaction{
+				{Hash: "f", Gas: 1, FeePerGas: "100", Signature: "CD"},
+			}},
+			expectedBlocks: map[string]int{"a@4": 1, "b@3": 0, "c@2": 0, "d@1": 0, "e@9": 0, "f@100": 1},
+		},
+		"optimal packing": {
+			forecast:       ForecastOptions{GasLimit: 20, Blocks: 2, Strategy: PackOptimal},
+			expectedBlocks: map[string]int{"a@4": 2, "b@3": 2, "c@2": 2, "d@1": 2, "e@9": 1},
+		},
+	}
+
+	for tName, tc := range tests {
+		tc := tc
+		t.Run(tName, func(t *testing.T) {
+			memPool := NewMemPool(tc.options...)
+			require.NoError(t, memPool.PushBatch(txs))
+			var before bytes.Buffer
+			require.NoError(t, memPool.WriteTransactions(&before))
+
+			inclusions := memPool.Forecast(tc.forecast)
+			require.Equal(t, tc.expectedBlocks, inclusionBlocks(inclusions))
+			require.Equal(t, "e", inclusions[0].Transaction.Hash)
+
+			// The pool is not modified
+			var after bytes.Buffer
+			require.NoError(t, memPool.WriteTransactions(&after))
+			require.Equal(t, before.String(), after.String())
+		})
+	}
+}
+
+func TestMemPool_ForecastNonceOrdering(t *testing.T) {
+	memPool := NewMemPool(WithNonceOrdering())
+	memPool.Push(Transaction{Hash: "alice0", Gas: 1, FeePerGas: "6", Signature: "CD", Sender: "alice", Nonce: 0, HasNonce: true})
+	memPool.Push(Transaction{Hash: "alice1", Gas: 1, FeePerGas: "9", Signature: "CD", Sender: "alice", Nonce: 1, HasNonce: true})
+	memPool.Push(Transaction{Hash: "alice3", Gas: 1, FeePerGas: "9", Signature: "CD", Sender: "alice", Nonce: 3, HasNonce: true})
+	memPool.Push(Transaction{Hash: "bob", Gas: 1, FeePerGas: "5", Signature: "CD"})
+
+	// The transaction with the nonce of the mined one arrives after the first block
+	inclusions := memPool.Forecast(ForecastOptions{GasLimit: 1, Blocks: 5, ArrivalsPerBlock: 1, Arrivals: []Transaction{
+		{Hash: "carol", Gas: 1, FeePerGas: "2", Signature: "CD"},
+		{Hash: "stale", Gas: 1, FeePerGas: "100", Signature: "CD", Sender: "alice", Nonce: 0, HasNonce: true},
+	}})
+	require.Equal(t, map[string]int{
+		"alice0@6": 1, "alice1@9": 2, "bob@5": 3, "carol@2": 4, "alice3@9": 0, "stale@100": 0,
+	}, inclusionBlocks(inclusions))
+	require.Equal(t, 4, memPool.Len())
+}
@@ -0,0 +1,74 @@
+package mempool
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+var ErrHashMismatch = errors.New("Hash mismatch")
+
+// CanonicalBytes returns the serialization of the transaction contents which its hash is computed from
+// It consists of the Key=Value tokens of the fields in a fixed order with the fee in its shortest exact decimal form,
+// the hash and the signature are not included
+func (t Transaction) CanonicalBytes() []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s=%d", KeyGas, t.Gas)
+	if t.hasFeePerGas() {
+		fmt.Fprintf(&b, " %s=%s", KeyFee, t.canonicalFee())
+	}
+	if t.Sender != "" {
+		fmt.Fprintf(&b, " %s=%s", KeySender, t.Sender)
+	}
+	if t.HasNonce {
+		fmt.Fprintf(&b, " %s=%d", KeyNonce, t.Nonce)
+	}
+	if t.IsDynamicFee() {
+		maxFee, maxTip := t.feeCaps()
+		fmt.Fprintf(&b, " %s=%s %s=%s", KeyMaxFee, formatDecimal(maxFee), KeyMaxPriorityFee, formatDecimal(maxTip))
+	}
+	return []byte(b.String())
+}
+
+// ComputeHash returns the upper case hex of the SHA-256 hash of the canonical serialization of the transaction
+func (t Transaction) ComputeHash() string {
+	return t.ComputeHashWith(sha256.New)
+}
+
+// ComputeHashWith returns the upper case hex of the hash of the canonical serialization of the transaction
+func (t Transaction) ComputeHashWith(newHash func() hash.Hash) string {
+	h := newHash()
+	h.Write(t.CanonicalBytes())
+	return fmt.Sprintf("%X", h.Sum(nil))
+}
+
+// VerifyHash checks that TxHash is the hash of the transaction contents, the hex case is ignored
+// SHA-256 is used if newHash is nil
+func (t Transaction) VerifyHash(newHash func() hash.Hash) error {
+	if computed, ok := t.verifyHash(newHash); !ok {
+		return fmt.Errorf("%w for transaction [%s], computed [%s]", ErrHashMismatch, t.Hash, computed)
+	}
+	return nil
+}
+
+// verifyHash returns the computed hash and whether it matches TxHash
+func (t Transaction) verifyHash(newHash func() hash.Hash) (string, bool) {
+	if newHash == nil {
+		newHash = sha256.New
+	}
+	computed := t.ComputeHashWith(newHash)
+	return computed, strings.EqualFold(computed, t.Hash)
+}
+
+// canonicalFee returns the shortest exact decimal form of the fee per gas
+func (t Transaction) canonicalFee() string {
+	if t.feePerGasNumeric != nil {
+		return formatDecimal(t.feePerGasNumeric)
+	}
+	if fee, ok := parseDecimal(t.FeePerGas); ok {
+		return formatDecimal(fee)
+	}
+	return t.FeePerGas
+}
@@ -0,0 +1,79 @@
+package mempool
+
+import (
+	"crypto/sha512"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransaction_CanonicalBytes(t *testing.T) {
+	tests := map[string]struct {
+		tx       Transaction
+		expected string
+	}{
+		"without sender": {
+			tx:       Transaction{Hash: "AB", Gas: 729000, FeePerGas: "0.11134106816568039", Signature: "CD"},
+			expected: "Gas=729000 FeePerGas=0.11134106816568039",
+		},
+		"with sender and exponent fee": {
+			tx:       Transaction{Hash: "AB", Gas: 748000, FeePerGas: "9.556431783046658e-05", Signature: "CD", Sender: "alice"},
+			expected: "Gas=748000 FeePerGas=0.00009556431783046658 Sender=alice",
+		},
+		"trailing zeros": {
+			tx:       Transaction{Hash: "AB", Gas: 1, FeePerGas: "2.500", Signature: "CD"},
+			expected: "Gas=1 FeePerGas=2.5",
+		},
+	}
+
+	for tName, tc := range tests {
+		tc := tc
+		t.Run(tName, func(t *testing.T) {
+			require.Equal(t, tc.expected, string(tc.tx.CanonicalBytes()))
+		})
+	}
+}
+
+func TestTransaction_ComputeHash(t *testing.T) {
+	tx, err := ReadTransaction("TxHash=AB Gas=729000 FeePerGas=0.11134106816568039 Signature=CD")
+	require.NoError(t, err)
+	require.Equal(t, "06577999BDDA0C57752478369A5D207FBD0A2AD88CF5D204F1A3E1C840E60358", tx.ComputeHash())
+	require.Equal(t, "90FBF733DBEF190CA405653C5642648FD3D6831E437C23D6ED7F275E5BFECFB541678FF3E33F892E84319A121FA60BCA8437068F51A9A8E9D7F5370F82632481", tx.ComputeHashWith(sha512.New))
+
+	// The hash doesn't depend on the textual form of the fee
+	other, err := ReadTransaction("TxHash=AB Gas=729000 FeePerGas=0.111341068165680390 Signature=CD")
+	require.NoError(t, err)
+	require.Equal(t, tx.ComputeHash(), other.ComputeHash())
+
+	withSender := Transaction{Gas: 748000, FeePerGas: "9.556431783046658e-05", Sender: "alice"}
+	require.Equal(t, "BC7F7833C0F8685FD8D9DDAC19955010A055E8F0FD473B1EAAA1B2FCB95D0D42", withSender.ComputeHash())
+}
+
+func TestTransaction_VerifyHash(t *testing.T) {
+	tx := Transaction{Hash: "06577999BDDA0C57752478369A5D207FBD0A2AD88CF5D204F1A3E1C840E60358", Gas: 729001, FeePerGas: "0.11134106816568039"}
+	err := tx.VerifyHash(nil)
+	require.ErrorIs(t, err, ErrHashMismatch)
+	require.EqualError(t, err, "Hash mismatch for transaction [06577999BDDA0C57752478369A5D207FBD0A2AD88CF5D204F1A3E1C840E60358], computed [C37D6F86398989FF463F2A3B6B1C93DE74C730481AD68E4FB01BD32A3BBE22DE]")
+
+	tx.Gas = 729000
+	require.NoError(t, tx.VerifyHash(nil))
+}
+
+func TestParseTransaction_VerifyHash(t *testing.T) {
+	valid := "TxHash=06577999bdda0c57752478369a5d207fbd0a2ad88cf5d204f1a3e1c840e60358 Gas=729000 FeePerGas=0.11134106816568039 Signature=CD"
+	tampered := "TxHash=06577999BDDA0C57752478369A5D207FBD0A2AD88CF5D204F1A3E1C840E60358 Gas=729001 FeePerGas=0.11134106816568039 Signature=CD"
+
+	_, err := ParseTransaction(valid, ParseOptions{VerifyHash: true})
+	require.NoError(t, err)
+
+	_, err = ParseTransaction(tampered, ParseOptions{VerifyHash: true})
+	require.ErrorIs(t, err, ErrHashMismatch)
+	require.EqualError(t, err, "Hash mismatch for field TxHash [06577999BDDA0C57752478369A5D207FBD0A2AD88CF5D204F1A3E1C840E60358]: computed [C37D6F86398989FF463F2A3B6B1C93DE74C730481AD68E4FB01BD32A3BBE22DE]")
+
+	_, err = ParseTransaction(valid, ParseOptions{VerifyHash: true, HashFunc: sha512.New})
+	require.ErrorIs(t, err, ErrHashMismatch)
+
+	// The hash is not verified by default
+	_, err = ParseTransaction(tampered, ParseOptions{})
+	require.NoError(t, err)
+}
@@ -0,0 +1,189 @@
+package mempool
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// JSONLinesCodec encodes every transaction as a JSON object on a separate line, e.g.
+// {"TxHash":"40E1...","Gas":729000,"FeePerGas":0.11134106816568039,"Signature":"6386..."}
+// The fees are JSON numbers with the same digits as the fields, so they are not altered by the encoding.
+// The extension fields follow the other ones in their order as JSON strings, numbers are accepted when decoding
+type JSONLinesCodec struct{}
+
+func (JSONLinesCodec) Name() string {
+	return "jsonl"
+}
+
+func (JSONLinesCodec) NewDecoder(reader io.Reader, options ParseOptions) Decoder {
+	return &jsonLinesDecoder{lines: newLineReader(reader), options: options}
+}
+
+func (JSONLinesCodec) NewEncoder(writer io.Writer) Encoder {
+	return &jsonLinesEncoder{writer: writer}
+}
+
+// jsonTransaction is the JSON object of a transaction
+type jsonTransaction struct {
+	Hash      string      `json:"TxHash"`
+	Gas       int         `json:"Gas"`
+	FeePerGas json.Number `json:"FeePerGas,omitempty"`
+	Signature string      `json:"Signature"`
+	Sender    string      `json:"Sender,omitempty"`
+	Nonce     *uint64     `json:"Nonce,omitempty"`
+	// The dynamic fee fields
+	MaxFeePerGas         json.Number `json:"MaxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas json.Number `json:"MaxPriorityFeePerGas,omitempty"`
+}
+
+type jsonLinesDecoder struct {
+	lines   *lineReader
+	options ParseOptions
+}
+
+func (d *jsonLinesDecoder) Decode() (Transaction, error) {
+	line, err := d.lines.next()
+	if err != nil {
+		return Transaction{}, err
+	}
+	tx, err := parseJSONTransaction(line, d.options)
+	if err != nil {
+		return tx, atLine(err, d.lines.number, line)
+	}
+	return tx, nil
+}
+
+func (d *jsonLinesDecoder) Record() (int, string) {
+	return d.lines.number, d.lines.line
+}
+
+// parseJSONTransaction converts the fields of the JSON object into tokens, so they are validated like the text ones
+// The gas, the fees and the nonce must be JSON numbers, the other known fields must be JSON strings and the extension fields
+// either strings or numbers
+func parseJSONTransaction(line string, options ParseOptions) (Transaction, error) {
+	invalidJSON := func(err error) error {
+		return &ParseError{Token: line, Text: line, Err: ErrInvalidToken, detail: err.Error()}
+	}
+
+	// The object is read token by token to keep the order of the extension fields
+	decoder := json.NewDecoder(strings.NewReader(line))
+	if t, err := decoder.Token(); err != nil {
+		return Transaction{}, invalidJSON(err)
+	} else if t != json.Delim('{') {
+		return Transaction{}, invalidJSON(fmt.Errorf("expected object, got %v", t))
+	}
+
+	tokensMap := make(map[string]token)
+	for decoder.More() {
+		t, err := decoder.Token()
+		if err != nil {
+			return Transaction{}, invalidJSON(err)
+		}
+		key := t.(string)
+		var raw json.RawMessage
+		if err = decoder.Decode(&raw); err != nil {
+			return Transaction{}, invalidJSON(err)
+		}
+
+		var value string
+		switch key {
+		case KeyGas, KeyFee, KeyNonce, KeyMaxFee, KeyMaxPriorityFee:
+			// The number is taken as it is written, so the fees keep their digits
+			value = string(raw)
+			if !isJSONNumber(value) {
+				err = ErrInvalidValueForField
+			}
+		case KeyHash, KeySignature, KeySender:
+			err = json.Unmarshal(raw, &value)
+		default:
+			if value = string(raw); !isJSONNumber(value) {
+				err = json.Unmarshal(raw, &value)
+			}
+		}
+		if err != nil {
+			return Transaction{}, &ParseError{Field: key, Token: string(raw), Text: line, Err: ErrInvalidValueForField}
+		}
+		addToken(tokensMap, token{key: key, value: value})
+	}
+	if _, err := decoder.Token(); err != nil {
+		return Transaction{}, invalidJSON(err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return Transaction{}, invalidJSON(errors.New("data after the object"))
+	}
+
+	return parseTokens(tokensMap, line, options)
+}
+
+type jsonLinesEncoder struct {
+	writer io.Writer
+}
+
+func (e *jsonLinesEncoder) Encode(tx Transaction) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	var nonce *uint64
+	if tx.HasNonce {
+		nonce = &tx.Nonce
+	}
+	err := encoder.Encode(jsonTransaction{
+		Hash:      tx.Hash,
+		Gas:       tx.Gas,
+		FeePerGas: jsonDecimal(tx.FeePerGas),
+		Signature: tx.Signature,
+		Sender:    tx.Sender,
+		Nonce:     nonce,
+
+		MaxFeePerGas:         jsonDecimal(tx.MaxFeePerGas),
+		MaxPriorityFeePerGas: jsonDecimal(tx.MaxPriorityFeePerGas),
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(tx.Extensions) > 0 {
+		// The extension fields are inserted before the closing brace and the line break
+		buf.Truncate(buf.Len() - 2)
+		for _, field := range tx.Extensions {
+			buf.WriteByte(',')
+			if err = encoder.Encode(field.Key); err != nil {
+				return err
+			}
+			buf.Truncate(buf.Len() - 1)
+			buf.WriteByte(':')
+			if err = encoder.Encode(field.Value); err != nil {
+				return err
+			}
+			buf.Truncate(buf.Len() - 1)
+		}
+		buf.WriteString("}\n")
+	}
+
+	_, err = e.writer.Write(buf.Bytes())
+	return err
+}
+
+func (e *jsonLinesEncoder) Close() error {
+	return nil
+}
+
+// jsonDecimal returns the decimal as it is if it is a valid JSON number, e.g. not .5, otherwise its shortest exact form
+// An empty decimal stays empty, so the field is omitted
+func jsonDecimal(s string) json.Number {
+	if isJSONNumber(s) {
+		return json.Number(s)
+	}
+	if value, ok := parseDecimal(s); ok {
+		return json.Number(formatDecimal(value))
+	}
+	return json.Number(s)
+}
+
+func isJSONNumber(s string) bool {
+	return len(s) > 0 && (s[0] == '-' || s[0] >= '0' && s[0] <= '9') && json.Valid([]byte(s))
+}
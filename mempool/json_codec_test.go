@@ -0,0 +1,131 @@
+package mempool
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONLinesCodec_Encode(t *testing.T) {
+	var buf bytes.Buffer
+	encoder := JSONLinesCodec{}.NewEncoder(&buf)
+	require.NoError(t, encoder.Encode(Transaction{Hash: "AB", Gas: 1000, FeePerGas: "9.556431783046658e-05", Signature: "CD"}))
+	require.NoError(t, encoder.Encode(Transaction{Hash: "EF", Gas: 2, FeePerGas: ".5", Signature: "CD", Sender: "<alice>"}))
+	require.NoError(t, encoder.Encode(Transaction{Hash: "GH", Gas: 3, FeePerGas: "1", Signature: "CD", Nonce: 0, HasNonce: true, Extensions: []Field{
+		{Key: "Chain", Value: "7"},
+		{Key: "Memo", Value: "<\"x\">"},
+	}}))
+	require.NoError(t, encoder.Close())
+
+	require.Equal(t, `{"TxHash":"AB","Gas":1000,"FeePerGas":9.556431783046658e-05,"Signature":"CD"}
+{"TxHash":"EF","Gas":2,"FeePerGas":0.5,"Signature":"CD","Sender":"<alice>"}
+{"TxHash":"GH","Gas":3,"FeePerGas":1,"Signature":"CD","Nonce":0,"Chain":"7","Memo":"<\"x\">"}
+`, buf.String())
+}
+
+func TestJSONLinesCodec_Decode(t *testing.T) {
+	tests := map[string]struct {
+		line          string
+		expectedTx    string
+		expectedError string
+	}{
+		"valid": {
+			line:       `{"TxHash":"AB","Gas":1000,"FeePerGas":0.11134106816568039,"Signature":"CD","Sender":"alice"}`,
+			expectedTx: "TxHash=AB Gas=1000 FeePerGas=0.11134106816568039 Signature=CD Sender=alice",
+		},
+		"extension fields keep their order": {
+			line:       `{"Signature":"CD","Chain":7,"FeePerGas":1e-3,"Gas":1,"TxHash":"AB","Memo":"x\u0079"}`,
+			expectedTx: "TxHash=AB Gas=1 FeePerGas=1e-3 Signature=CD Chain=7 Memo=xy",
+		},
+		"nonce": {
+			line:       `{"TxHash":"AB","Gas":1,"FeePerGas":1,"Signature":"CD","Sender":"alice","Nonce":18446744073709551615}`,
+			expectedTx: "TxHash=AB Gas=1 FeePerGas=1 Signature=CD Sender=alice Nonce=18446744073709551615",
+		},
+		"nonce as string": {
+			line:          `{"TxHash":"AB","Gas":1,"FeePerGas":1,"Signature":"CD","Nonce":"1"}`,
+			expectedError: `Line 1: Invalid value for field Nonce ["1"]`,
+		},
+		"extension field with invalid value": {
+			line:          `{"TxHash":"AB","Gas":1,"FeePerGas":1,"Signature":"CD","Extra":[1]}`,
+			expectedError: `Line 1: Invalid value for field Extra [[1]]`,
+		},
+		"extension field with whitespace": {
+			line:          `{"TxHash":"AB","Gas":1,"FeePerGas":1,"Signature":"CD","Memo":"a b"}`,
+			expectedError: `Line 1: Invalid value for field Memo [a b]`,
+		},
+		"data after the object": {
+			line:          `{"TxHash":"AB","Gas":1,"FeePerGas":1,"Signature":"CD"} {}`,
+			expectedError: `Line 1: Invalid token [{"TxHash":"AB","Gas":1,"FeePerGas":1,"Signature":"CD"} {}]: data after the object`,
+		},
+		"missing field": {
+			line:          `{"TxHash":"AB","Gas":1,"Signature":"CD"}`,
+			expectedError: `Line 1: Field FeePerGas not found in line [{"TxHash":"AB","Gas":1,"Signature":"CD"}]`,
+		},
+		"fee as string": {
+			line:          `{"TxHash":"AB","Gas":1,"FeePerGas":"1","Signature":"CD"}`,
+			expectedError: `Line 1: Invalid value for field FeePerGas ["1"]`,
+		},
+		"fractional gas": {
+			line:          `{"TxHash":"AB","Gas":1.5,"FeePerGas":1,"Signature":"CD"}`,
+			expectedError: `Line 1: Invalid value for field Gas [1.5]`,
+		},
+		"hash as number": {
+			line:          `{"TxHash":12,"Gas":1,"FeePerGas":1,"Signature":"CD"}`,
+			expectedError: `Line 1: Invalid value for field TxHash [12]`,
+		},
+	}
+
+	for tName, tc := range tests {
+		tc := tc
+		t.Run(tName, func(t *testing.T) {
+			decoder := JSONLinesCodec{}.NewDecoder(strings.NewReader(tc.line), ParseOptions{})
+			tx, err := decoder.Decode()
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedTx, tx.String())
+
+			_, err = decoder.Decode()
+			require.Equal(t, io.EOF, err)
+		})
+	}
+}
+
+func TestJSONLinesCodec_DecodeInvalidJSON(t *testing.T) {
+	decoder := JSONLinesCodec{}.NewDecoder(strings.NewReader("{}\n[1, 2]\n{\"TxHash\""), ParseOptions{})
+	for _, line := range []int{1, 2, 3} {
+		_, err := decoder.Decode()
+		var parseErr *ParseError
+		require.ErrorAs(t, err, &parseErr)
+		require.Equal(t, line, parseErr.Line)
+	}
+
+	_, err := decoder.Decode()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestJSONLinesCodec_RoundTrip(t *testing.T) {
+	input, err := os.ReadFile("../transactions.txt")
+	require.NoError(t, err)
+
+	memPool := NewMemPool(WithTieBreak(TieBreakArrival), WithCapacity(10000))
+	require.NoError(t, memPool.ReadTransactions(bytes.NewReader(input)))
+	var jsonOutput bytes.Buffer
+	require.NoError(t, memPool.WriteTransactionsWith(&jsonOutput, JSONLinesCodec{}))
+
+	jsonPool := NewMemPool(WithTieBreak(TieBreakArrival), WithCapacity(10000))
+	report, err := jsonPool.ReadTransactionsWith(&jsonOutput, ReadOptions{Codec: JSONLinesCodec{}})
+	require.NoError(t, err)
+	require.Equal(t, 7500, report.Accepted)
+
+	var expected, actual bytes.Buffer
+	require.NoError(t, memPool.WriteTransactions(&expected))
+	require.NoError(t, jsonPool.WriteTransactions(&actual))
+	require.Equal(t, expected.String(), actual.String())
+}
@@ -0,0 +1,152 @@
+package mempool
+
+// KeyedPriorityQueue is a PriorityQueue which additionally indexes the items by a key
+// This allows to find, remove and update a specific item with O(log(n)) complexity
+// Every key is present in the queue at most once
+type KeyedPriorityQueue[K comparable, T any] struct {
+	queue PriorityQueueOf[T]
+	// items references the max heap items by their keys
+	items map[K]*queueItem[T]
+	key   func(item T) K
+}
+
+// NewKeyedPriorityQueueFunc creates a keyed priority queue with the given capacity
+// less reports whether a has a lower priority than b, key returns the key of the item
+func NewKeyedPriorityQueueFunc[K comparable, T any](capacity int, less func(a, b T) bool, key func(item T) K) KeyedPriorityQueue[K, T] {
+	return KeyedPriorityQueue[K, T]{
+		queue: NewPriorityQueueFunc(capacity, less),
+		items: make(map[K]*queueItem[T]),
+		key:   key,
+	}
+}
+
+// Push adds an item to the priority queue
+// If an item with the same key is already in the queue, it is replaced
+// When the capacity is surpassed, item with the lowest priority will be dropped
+func (m *KeyedPriorityQueue[K, T]) Push(item T) PushResult[T] {
+	k := m.key(item)
+	if qItem, ok := m.items[k]; ok {
+		replaced := qItem.item
+		m.queue.update(qItem, item)
+		return PushResult[T]{Admitted: true, Replaced: &replaced}
+	}
+
+	added, dropped := m.queue.push(item)
+	m.items[k] = added
+	if dropped != nil {
+		delete(m.items, m.key(dropped.item))
+	}
+	return newPushResult(added, dropped)
+}
+
+// PushBatch adds the items to the priority queue, building the heaps in O(n) rather than pushing the items one by one
+// The items with keys already in the queue replace the queued ones, and within the batch the last item with a key wins.
+// When the capacity is surpassed, the items with the lowest priority are dropped.
+// It returns the dropped items from the lowest priority and the replaced items in the order of replacement
+func (m *KeyedPriorityQueue[K, T]) PushBatch(items []T) (dropped, replaced []T) {
+	fresh := make([]T, 0, len(items))
+	// freshIdx is the position of the item with the key in fresh
+	freshIdx := make(map[K]int)
+	for _, item := range items {
+		k := m.key(item)
+		if qItem, ok := m.items[k]; ok {
+			replaced = append(replaced, qItem.item)
+			m.queue.update(qItem, item)
+			continue
+		}
+		if i, ok := freshIdx[k]; ok {
+			replaced = append(replaced, fresh[i])
+			fresh[i] = item
+			continue
+		}
+		freshIdx[k] = len(fresh)
+		fresh = append(fresh, item)
+	}
+
+	added, droppedItems := m.queue.pushBatch(fresh)
+	for _, qItem := range added {
+		m.items[m.key(qItem.item)] = qItem
+	}
+	for _, qItem := range droppedItems {
+		delete(m.items, m.key(qItem.item))
+		dropped = append(dropped, qItem.item)
+	}
+	return dropped, replaced
+}
+
+// Pop retrieves and removes the item with the highest priority
+// If the queue is empty, the function will panic
+func (m *KeyedPriorityQueue[K, T]) Pop() T {
+	item := m.queue.Pop()
+	delete(m.items, m.key(item))
+	return item
+}
+
+// Get returns the item with the given key
+func (m KeyedPriorityQueue[K, T]) Get(key K) (T, bool) {
+	qItem, ok := m.items[key]
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return qItem.item, true
+}
+
+// Contains reports whether an item with the given key is in the queue
+func (m KeyedPriorityQueue[K, T]) Contains(key K) bool {
+	_, ok := m.items[key]
+	return ok
+}
+
+// Remove removes the item with the given key and returns it
+// The second return value is false if there is no such item
+func (m *KeyedPriorityQueue[K, T]) Remove(key K) (T, bool) {
+	qItem, ok := m.items[key]
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	m.queue.remove(qItem)
+	delete(m.items, key)
+	return qItem.item, true
+}
+
+// Update replaces the item with the given key and moves it according to its new priority
+// The new item must have the same key. The return value is false if there is no item with the key
+func (m *KeyedPriorityQueue[K, T]) Update(key K, item T) bool {
+	qItem, ok := m.items[key]
+	if !ok {
+		return false
+	}
+	m.queue.update(qItem, item)
+	return true
+}
+
+// Peek returns the item with the highest priority without removing it
+func (m KeyedPriorityQueue[K, T]) Peek() (T, bool) {
+	return m.queue.Peek()
+}
+
+// PeekLowest returns the item with the lowest priority without removing it
+func (m KeyedPriorityQueue[K, T]) PeekLowest() (T, bool) {
+	return m.queue.PeekLowest()
+}
+
+// Descend calls fn for the items from the highest to the lowest priority until fn returns false
+func (m KeyedPriorityQueue[K, T]) Descend(fn func(item T) bool) {
+	m.queue.Descend(fn)
+}
+
+// Ascend calls fn for the items from the lowest to the highest priority until fn returns false
+func (m KeyedPriorityQueue[K, T]) Ascend(fn func(item T) bool) {
+	m.queue.Ascend(fn)
+}
+
+// each calls fn for the items in no particular order
+func (m KeyedPriorityQueue[K, T]) each(fn func(item T)) {
+	m.queue.each(fn)
+}
+
+func (m KeyedPriorityQueue[K, T]) Len() int {
+	return m.queue.Len()
+}
@@ -0,0 +1,182 @@
+package mempool
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testKeyedItem struct {
+	key      string
+	priority int
+}
+
+func newTestKeyedQueue(capacity int) KeyedPriorityQueue[string, testKeyedItem] {
+	return NewKeyedPriorityQueueFunc(capacity, func(a, b testKeyedItem) bool {
+		return a.priority < b.priority
+	}, func(item testKeyedItem) string {
+		return item.key
+	})
+}
+
+func drainKeyedQueue(q *KeyedPriorityQueue[string, testKeyedItem]) []testKeyedItem {
+	var output []testKeyedItem
+	for q.Len() > 0 {
+		output = append(output, q.Pop())
+	}
+	return output
+}
+
+func TestKeyedPriorityQueue_GetAndContains(t *testing.T) {
+	q := newTestKeyedQueue(10)
+	q.Push(testKeyedItem{key: "a", priority: 1})
+	q.Push(testKeyedItem{key: "b", priority: 2})
+
+	require.True(t, q.Contains("a"))
+	require.False(t, q.Contains("c"))
+
+	item, ok := q.Get("b")
+	require.True(t, ok)
+	require.Equal(t, testKeyedItem{key: "b", priority: 2}, item)
+
+	_, ok = q.Get("c")
+	require.False(t, ok)
+
+	q.Pop()
+	require.False(t, q.Contains("b"))
+}
+
+func TestKeyedPriorityQueue_Remove(t *testing.T) {
+	q := newTestKeyedQueue(10)
+	for i, key := range []string{"a", "b", "c", "d", "e"} {
+		q.Push(testKeyedItem{key: key, priority: i})
+	}
+
+	item, ok := q.Remove("c")
+	require.True(t, ok)
+	require.Equal(t, testKeyedItem{key: "c", priority: 2}, item)
+	_, ok = q.Remove("c")
+	require.False(t, ok)
+
+	lowest, ok := q.PeekLowest()
+	require.True(t, ok)
+	require.Equal(t, "a", lowest.key)
+
+	require.Equal(t, []testKeyedItem{{key: "e", priority: 4}, {key: "d", priority: 3}, {key: "b", priority: 1}, {key: "a", priority: 0}}, drainKeyedQueue(&q))
+}
+
+func TestKeyedPriorityQueue_Update(t *testing.T) {
+	q := newTestKeyedQueue(10)
+	for i, key := range []string{"a", "b", "c"} {
+		q.Push(testKeyedItem{key: key, priority: i})
+	}
+
+	require.True(t, q.Update("a", testKeyedItem{key: "a", priority: 10}))
+	require.False(t, q.Update("x", testKeyedItem{key: "x", priority: 10}))
+	// pushing an existing key replaces the item
+	q.Push(testKeyedItem{key: "c", priority: -1})
+
+	require.Equal(t, 3, q.Len())
+	lowest, _ := q.PeekLowest()
+	require.Equal(t, "c", lowest.key)
+	require.Equal(t, []testKeyedItem{{key: "a", priority: 10}, {key: "b", priority: 1}, {key: "c", priority: -1}}, drainKeyedQueue(&q))
+}
+
+func TestKeyedPriorityQueue_CapacityDropsKeys(t *testing.T) {
+	q := newTestKeyedQueue(2)
+	q.Push(testKeyedItem{key: "a", priority: 2})
+	q.Push(testKeyedItem{key: "b", priority: 3})
+	q.Push(testKeyedItem{key: "c", priority: 1})
+	q.Push(testKeyedItem{key: "d", priority: 4})
+
+	require.False(t, q.Contains("a"))
+	require.False(t, q.Contains("c"))
+	require.True(t, q.Contains("b"))
+	require.True(t, q.Contains("d"))
+}
+
+func TestKeyedPriorityQueue_PushResult(t *testing.T) {
+	q := newTestKeyedQueue(2)
+	require.Equal(t, PushResult[testKeyedItem]{Admitted: true}, q.Push(testKeyedItem{key: "a", priority: 2}))
+	require.Equal(t, PushResult[testKeyedItem]{Admitted: true}, q.Push(testKeyedItem{key: "b", priority: 3}))
+	require.Equal(t, PushResult[testKeyedItem]{Admitted: false}, q.Push(testKeyedItem{key: "c", priority: 1}))
+
+	result := q.Push(testKeyedItem{key: "b", priority: 5})
+	require.True(t, result.Admitted)
+	require.Nil(t, result.Evicted)
+	require.Equal(t, testKeyedItem{key: "b", priority: 3}, *result.Replaced)
+
+	result = q.Push(testKeyedItem{key: "d", priority: 4})
+	require.True(t, result.Admitted)
+	require.Equal(t, testKeyedItem{key: "a", priority: 2}, *result.Evicted)
+	require.Nil(t, result.Replaced)
+}
+
+func TestKeyedPriorityQueue_RandomOperations(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	q := newTestKeyedQueue(1000)
+	expected := make(map[string]int)
+	keys := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"}
+
+	for i := 0; i < 2000; i++ {
+		key := keys[rnd.Intn(len(keys))]
+		switch rnd.Intn(3) {
+		case 0:
+			priority := rnd.Intn(100)
+			q.Push(testKeyedItem{key: key, priority: priority})
+			expected[key] = priority
+		case 1:
+			_, ok := q.Remove(key)
+			_, expectedOk := expected[key]
+			require.Equal(t, expectedOk, ok)
+			delete(expected, key)
+		case 2:
+			priority := rnd.Intn(100)
+			_, expectedOk := expected[key]
+			require.Equal(t, expectedOk, q.Update(key, testKeyedItem{key: key, priority: priority}))
+			if expectedOk {
+				expected[key] = priority
+			}
+		}
+		require.Equal(t, len(expected), q.Len())
+	}
+
+	var expectedPriorities []int
+	for _, priority := range expected {
+		expectedPriorities = append(expectedPriorities, priority)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(expectedPriorities)))
+
+	var priorities []int
+	for _, item := range drainKeyedQueue(&q) {
+		require.Equal(t, expected[item.key], item.priority)
+		priorities = append(priorities, item.priority)
+	}
+	require.Equal(t, expectedPriorities, priorities)
+}
+
+func TestKeyedPriorityQueue_PushBatch(t *testing.T) {
+	q := newTestKeyedQueue(3)
+	q.Push(testKeyedItem{key: "a", priority: 5})
+	q.Push(testKeyedItem{key: "b", priority: 1})
+
+	dropped, replaced := q.PushBatch([]testKeyedItem{
+		{key: "c", priority: 2},
+		{key: "a", priority: 6},
+		{key: "d", priority: 3},
+		{key: "c", priority: 4},
+		{key: "e", priority: 0},
+	})
+	require.Equal(t, []testKeyedItem{{key: "e", priority: 0}, {key: "b", priority: 1}}, dropped)
+	require.Equal(t, []testKeyedItem{{key: "a", priority: 5}, {key: "c", priority: 2}}, replaced)
+
+	require.False(t, q.Contains("b"))
+	require.False(t, q.Contains("e"))
+	require.Equal(t, []testKeyedItem{
+		{key: "a", priority: 6},
+		{key: "c", priority: 4},
+		{key: "d", priority: 3},
+	}, drainKeyedQueue(&q))
+}
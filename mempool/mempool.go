@@ -1,70 +1,555 @@
 package mempool
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"io"
-	"strings"
+	"math"
+	"math/big"
+	"sync"
+	"time"
 )
 
 const memPoolCapacity = 5000
 
+// MemPool keeps the transactions prioritized by their fee
+// It is safe for concurrent use by multiple goroutines
 type MemPool struct {
-	queue *PriorityQueue
+	mu sync.Mutex
+	// queue keeps the transactions which can be popped
+	queue *KeyedPriorityQueue[string, poolEntry]
+	// parked keeps the transactions whose max fee per gas is below the base fee
+	parked *KeyedPriorityQueue[string, poolEntry]
+	// pushed is closed and replaced every time a transaction is added in order to wake up the consumers waiting in PopWait
+	pushed chan struct{}
+	now    func() time.Time
+
+	// seq is the arrival sequence number of the last pushed transaction
+	seq uint64
+
+	capacity     int
+	evictionHook func(eviction Eviction)
+	tieBreak     TieBreak
+
+	parseOptions       ParseOptions
+	verifier           SignatureVerifier
+	verificationPolicy VerificationPolicy
+	// quarantine keeps the transactions with invalid signatures
+	quarantine []Transaction
+
+	// nonceOrdering enables the nonce ordering mode, see WithNonceOrdering. In this mode the queue only has the
+	// transactions without a sender or a nonce and the next transaction of every account
+	nonceOrdering bool
+	accounts      map[string]*account
+	// nonces locates the transactions of the accounts by their keys
+	nonces map[string]senderNonce
+	// queued keeps the transactions of the accounts which wait for a missing nonce
+	queued *KeyedPriorityQueue[string, poolEntry]
+	// heads is the number of the accounts with a transaction in the queue or in the parked set
+	heads int
+	// senderNonces locates the transactions with a sender and a nonce outside of the nonce ordering mode,
+	// so the conflicting ones are replaced
+	senderNonces map[senderNonce]string
+
+	// replaceByFee enables the replace-by-fee policy with the bump percentage, see WithReplaceByFee
+	replaceByFee bool
+	replaceBump  int
+
+	// baseFee is the base fee per gas the priorities are calculated with, nil if there is none, see SetBaseFee
+	baseFee *big.Rat
+}
+
+// poolEntry is a transaction together with the bookkeeping data of the pool
+type poolEntry struct {
+	tx    Transaction
+	added time.Time
+	seq   uint64
+	// priority is the total fee of the transaction with the base fee of the pool, it must not be modified
+	priority *big.Rat
+}
+
+// entryKey returns the key the entry is kept by in the queues of the pool
+func entryKey(e poolEntry) string {
+	return normalizeHash(e.tx.Hash)
+}
+
+func NewMemPool(options ...Option) *MemPool {
+	m := &MemPool{
+		pushed:   make(chan struct{}),
+		now:      time.Now,
+		capacity: memPoolCapacity,
+	}
+	for _, option := range options {
+		option(m)
+	}
+
+	// The capacity covers the transactions aside of the queue as well, so it is enforced by the pool
+	m.queue = m.newQueue()
+	m.parked = m.newQueue()
+	m.queued = m.newQueue()
+	m.accounts = make(map[string]*account)
+	m.nonces = make(map[string]senderNonce)
+	m.senderNonces = make(map[senderNonce]string)
+	return m
+}
+
+func (m *MemPool) newQueue() *KeyedPriorityQueue[string, poolEntry] {
+	q := NewKeyedPriorityQueueFunc(math.MaxInt, m.less, entryKey)
+	return &q
 }
 
-func NewMemPool() MemPool {
-	q := NewPriorityQueue(memPoolCapacity)
-	return MemPool{queue: &q}
+// newEntry creates the entry of the transaction arriving to the pool
+func (m *MemPool) newEntry(tx Transaction, added time.Time) poolEntry {
+	m.seq++
+	return poolEntry{tx: tx, added: added, seq: m.seq, priority: m.priority(tx)}
 }
 
-func (m MemPool) ReadTransactions(reader io.Reader) error {
-	bReader := bufio.NewReader(reader)
-	var isEof bool
-	for !isEof {
-		line, err := bReader.ReadString('\n')
-		if err != nil {
-			if err == io.EOF {
-				isEof = true
-				err = nil
-			} else {
+// Push adds the transaction to the pool
+// A transaction with the same hash which is already in the pool is replaced, the hex case of the hashes is ignored,
+// and so is the one with the same sender and nonce.
+// When the capacity is surpassed, the transaction with the lowest priority will be dropped. If it is the pushed
+// transaction itself, it is not admitted, which is reported to the eviction hook as well.
+// In the nonce ordering mode a transaction with a nonce which was already popped is not admitted either.
+// A transaction whose max fee per gas is below the base fee is parked, it is kept but can't be popped.
+// The replaced transaction, if any, is reported in the result. With the replace-by-fee policy the transaction
+// is rejected with ErrReplacementUnderpriced if it doesn't pay enough to replace the conflicting one
+func (m *MemPool) Push(tx Transaction) (PushResult[Transaction], error) {
+	m.mu.Lock()
+	if err := m.checkReplacement(tx, nil); err != nil {
+		m.mu.Unlock()
+		return PushResult[Transaction]{}, err
+	}
+	result, evictions := m.pushEntry(m.newEntry(tx, m.now()))
+	if result.Admitted {
+		m.wakeConsumers()
+	}
+	m.mu.Unlock()
+
+	m.notifyEvictions(evictions)
+	return result, nil
+}
+
+// pushEntry adds the entry to the pool
+// A transaction with the same hash or the same sender and nonce is replaced
+func (m *MemPool) pushEntry(e poolEntry) (PushResult[Transaction], []Eviction) {
+	var result PushResult[Transaction]
+	var evictions []Eviction
+	tx := e.tx
+	if m.ordered(tx) {
+		if a, ok := m.accounts[tx.Sender]; ok && a.nextKnown && tx.Nonce < a.next {
+			return result, []Eviction{{Transaction: tx, Reason: EvictionStale}}
+		}
+	}
+
+	key := entryKey(e)
+	if replaced, ok := m.removeEntry(key); ok {
+		result.Replaced = &replaced.tx
+		evictions = append(evictions, Eviction{Transaction: replaced.tx, Reason: EvictionReplaced})
+	}
+	if m.ordered(tx) {
+		if replaced, ok := m.account(tx.Sender).txs[tx.Nonce]; ok {
+			m.removeEntry(entryKey(replaced))
+			result.Replaced = &replaced.tx
+			evictions = append(evictions, Eviction{Transaction: replaced.tx, Reason: EvictionReplaced})
+		}
+		// The account is looked up again, as it is deleted once the replaced transaction was its last one
+		a := m.account(tx.Sender)
+		a.txs[tx.Nonce] = e
+		m.nonces[key] = senderNonce{sender: tx.Sender, nonce: tx.Nonce}
+		m.reorganize(tx.Sender)
+	} else {
+		if sn, ok := m.unorderedNonce(tx); ok {
+			if replacedKey, ok := m.senderNonces[sn]; ok {
+				replaced, _ := m.unplace(replacedKey)
+				result.Replaced = &replaced.tx
+				evictions = append(evictions, Eviction{Transaction: replaced.tx, Reason: EvictionReplaced})
+			}
+		}
+		m.place(e)
+	}
+
+	result.Admitted = true
+	for _, evicted := range m.evictOverCapacity() {
+		if entryKey(evicted) == key {
+			result.Admitted = false
+		} else {
+			result.Evicted = &evicted.tx
+		}
+		evictions = append(evictions, Eviction{Transaction: evicted.tx, Reason: EvictionCapacity})
+	}
+	return result, evictions
+}
+
+// evictOverCapacity removes the lowest priority transactions while the capacity is surpassed and returns them
+// The parked transactions are evicted first, then the queued ones and only then the poppable ones
+func (m *MemPool) evictOverCapacity() []poolEntry {
+	var evicted []poolEntry
+	for m.len() > m.capacity {
+		lowest, ok := m.parked.PeekLowest()
+		if !ok {
+			lowest, ok = m.queued.PeekLowest()
+		}
+		if !ok {
+			lowest, _ = m.queue.PeekLowest()
+		}
+		m.removeEntry(entryKey(lowest))
+		evicted = append(evicted, lowest)
+	}
+	return evicted
+}
+
+// PushBatch adds the transactions to the pool at once, which is faster than pushing them one by one
+// The transactions arrive in the order of the slice, the hashes are handled as in Push. The transactions dropped
+// due to the capacity, including the ones of the batch, are reported to the eviction hook.
+// In the nonce ordering mode, with a base fee and if any of the transactions has a sender and a nonce, the
+// transactions are pushed one by one.
+// With the replace-by-fee policy none of the transactions is added if any of them is an underpriced replacement
+func (m *MemPool) PushBatch(txs []Transaction) error {
+	if len(txs) == 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	if m.replaceByFee {
+		batch := newReplacementBatch()
+		for _, tx := range txs {
+			if err := m.checkReplacement(tx, batch); err != nil {
+				m.mu.Unlock()
 				return err
 			}
 		}
-		// Skip whitespace lines
-		if len(strings.TrimSpace(line)) == 0 {
-			continue
+	}
+	now := m.now()
+	if m.nonceOrdering || m.baseFee != nil || hasSenderNonce(txs) {
+		var evictions []Eviction
+		for _, tx := range txs {
+			_, txEvictions := m.pushEntry(m.newEntry(tx, now))
+			evictions = append(evictions, txEvictions...)
 		}
-		tx, err := ReadTransaction(line)
-		if err != nil {
-			return err
+		if m.queue.Len() > 0 {
+			m.wakeConsumers()
 		}
+		m.mu.Unlock()
 
-		m.queue.Push(tx)
+		m.notifyEvictions(evictions)
+		return nil
+	}
+	entries := make([]poolEntry, 0, len(txs))
+	for _, tx := range txs {
+		entries = append(entries, m.newEntry(tx, now))
 	}
+	_, replaced := m.queue.PushBatch(entries)
+	// The batch has no senders with nonces, but the entries it replaces by their hashes may have them
+	for _, e := range replaced {
+		m.forgetNonce(e)
+	}
+	dropped := m.evictOverCapacity()
 
+	evictions := make([]Eviction, 0, len(dropped)+len(replaced))
+	for _, e := range replaced {
+		evictions = append(evictions, Eviction{Transaction: e.tx, Reason: EvictionReplaced})
+	}
+	for _, e := range dropped {
+		evictions = append(evictions, Eviction{Transaction: e.tx, Reason: EvictionCapacity})
+	}
+	if m.queue.Len() > 0 {
+		m.wakeConsumers()
+	}
+	m.mu.Unlock()
+
+	m.notifyEvictions(evictions)
 	return nil
 }
 
-func (m MemPool) WriteTransactions(writer io.Writer) error {
+// wakeConsumers wakes up the consumers waiting in PopWait, it must be called holding the lock
+func (m *MemPool) wakeConsumers() {
+	close(m.pushed)
+	m.pushed = make(chan struct{})
+}
+
+// Pop retrieves and removes the transaction with the highest priority
+// The second return value is false if the pool is empty or no transaction can be popped, i.e. all of them are
+// parked or, in the nonce ordering mode, queued
+func (m *MemPool) Pop() (Transaction, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if m.queue.Len() == 0 {
-		return nil
+		return Transaction{}, false
 	}
+	return m.pop().tx, true
+}
+
+// PopWait retrieves and removes the transaction with the highest priority
+// If the pool is empty, it blocks until a transaction is pushed or the context is done
+func (m *MemPool) PopWait(ctx context.Context) (Transaction, error) {
+	for {
+		m.mu.Lock()
+		if m.queue.Len() > 0 {
+			e := m.pop()
+			m.mu.Unlock()
+			return e.tx, nil
+		}
+		pushed := m.pushed
+		m.mu.Unlock()
 
-	for  {
-		item := m.queue.Pop()
-		_, err := writer.Write([]byte(fmt.Sprintf("%s", item)))
-		if err != nil {
-			return err
+		select {
+		case <-pushed:
+		case <-ctx.Done():
+			return Transaction{}, ctx.Err()
 		}
-		if m.queue.Len() == 0 {
+	}
+}
+
+// Peek returns the transaction with the highest priority without removing it
+// The second return value is false if the pool is empty
+func (m *MemPool) Peek() (Transaction, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.queue.Peek()
+	return e.tx, ok
+}
+
+// Get returns the transaction with the given hash
+func (m *MemPool) Get(hash string) (Transaction, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.getEntry(normalizeHash(hash))
+	return e.tx, ok
+}
+
+// Contains reports whether the transaction with the given hash is in the pool
+func (m *MemPool) Contains(hash string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.getEntry(normalizeHash(hash))
+	return ok
+}
+
+// Remove removes the transaction with the given hash, e.g. when it has been mined
+// The second return value is false if there is no such transaction in the pool
+func (m *MemPool) Remove(hash string) (Transaction, bool) {
+	m.mu.Lock()
+	e, ok := m.removeEntry(normalizeHash(hash))
+	m.mu.Unlock()
+
+	if ok {
+		m.notifyEvictions([]Eviction{{Transaction: e.tx, Reason: EvictionRemoved}})
+	}
+	return e.tx, ok
+}
+
+// Expire removes the transactions which were added to the pool before the cutoff time
+// It returns the number of the removed transactions
+func (m *MemPool) Expire(cutoff time.Time) int {
+	m.mu.Lock()
+	var expired []string
+	m.forEach(func(e poolEntry) {
+		if e.added.Before(cutoff) {
+			expired = append(expired, normalizeHash(e.tx.Hash))
+		}
+	})
+	evictions := make([]Eviction, 0, len(expired))
+	for _, hash := range expired {
+		e, _ := m.removeEntry(hash)
+		evictions = append(evictions, Eviction{Transaction: e.tx, Reason: EvictionExpired})
+	}
+	m.mu.Unlock()
+
+	m.notifyEvictions(evictions)
+	return len(evictions)
+}
+
+// Quarantined returns the transactions which were not added to the pool because their signatures don't verify
+func (m *MemPool) Quarantined() []Transaction {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Transaction(nil), m.quarantine...)
+}
+
+func (m *MemPool) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.len()
+}
+
+// less reports whether the entry a has a lower priority than b
+// The order of the entries with equal priority is defined by the tie break mode of the pool
+func (m *MemPool) less(a, b poolEntry) bool {
+	if c := a.priority.Cmp(b.priority); c != 0 {
+		return c < 0
+	}
+	if m.tieBreak&TieBreakArrival != 0 && a.seq != b.seq {
+		// The transaction which arrived later goes after the earlier one and is evicted first
+		return a.seq > b.seq
+	}
+	if m.tieBreak&TieBreakHash != 0 && a.tx.Hash != b.tx.Hash {
+		return a.tx.Hash > b.tx.Hash
+	}
+	return false
+}
+
+// notifyEvictions reports the evictions to the hook, it must be called without holding the lock
+func (m *MemPool) notifyEvictions(evictions []Eviction) {
+	if m.evictionHook == nil {
+		return
+	}
+	for _, eviction := range evictions {
+		m.evictionHook(eviction)
+	}
+}
+
+// ReadTransactions reads the transactions line by line and adds them to the pool
+// If a line can't be read, the returned error is a *ParseError with the number of the line
+func (m *MemPool) ReadTransactions(reader io.Reader) error {
+	_, err := m.ReadTransactionsWith(reader, ReadOptions{})
+	return err
+}
+
+// ReadTransactionsWith reads the transactions with the codec of the options and adds them to the pool
+// Unless the reading is lenient, it stops on the first invalid record returning a *ParseError with the number of the record.
+// The report tells how many records were accepted and rejected so far.
+// In the atomic mode the whole input is read before the transactions are added to the pool in a batch, so if the
+// reading fails, neither the pool nor the quarantine is modified and nothing is reported as accepted or quarantined.
+// The underpriced replacements of the replace-by-fee policy are rejected like the invalid records
+func (m *MemPool) ReadTransactionsWith(reader io.Reader, options ReadOptions) (ReadReport, error) {
+	var report ReadReport
+	// batch and quarantine keep the transactions of the atomic mode until the whole input is read
+	var batch, quarantine []Transaction
+	replacements := newReplacementBatch()
+	decoder := options.codec().NewDecoder(reader, m.parseOptions)
+	for {
+		tx, err := decoder.Decode()
+		if err == io.EOF {
 			break
 		}
-		_, err = writer.Write([]byte("\n"))
-		if err != nil {
-			return err
+
+		var parseErr *ParseError
+		if err != nil && !errors.As(err, &parseErr) {
+			return discardBatch(report, options), err
+		}
+		if err == nil {
+			quarantined, txErr := m.verify(tx)
+			if quarantined {
+				report.Quarantined++
+				if options.Atomic {
+					quarantine = append(quarantine, tx)
+				} else {
+					m.addToQuarantine(tx)
+				}
+				continue
+			}
+			if txErr == nil {
+				if options.Atomic {
+					txErr = m.checkBatchReplacement(tx, replacements)
+				} else {
+					_, txErr = m.Push(tx)
+				}
+			}
+			if txErr != nil {
+				number, text := decoder.Record()
+				parseErr = atLine(txErr, number, text)
+			}
+		}
+		if parseErr != nil {
+			if !options.Lenient {
+				return discardBatch(report, options), parseErr
+			}
+			report.Rejected++
+			report.Errors = append(report.Errors, parseErr)
+			if options.MaxErrors > 0 && report.Rejected > options.MaxErrors {
+				return discardBatch(report, options), fmt.Errorf("%w: more than %d lines rejected", ErrTooManyErrors, options.MaxErrors)
+			}
+			continue
+		}
+
+		if options.Atomic {
+			batch = append(batch, tx)
 		}
+		report.Accepted++
 	}
 
-	return nil
+	if options.Atomic {
+		// The replacements are checked again, as the pool may have changed while the input was read
+		if err := m.PushBatch(batch); err != nil {
+			return discardBatch(report, options), err
+		}
+		m.addToQuarantine(quarantine...)
+	}
+	return report, nil
+}
+
+// discardBatch clears the counts of the transactions which are not applied to the pool when the atomic reading fails
+func discardBatch(report ReadReport, options ReadOptions) ReadReport {
+	if options.Atomic {
+		report.Accepted = 0
+		report.Quarantined = 0
+	}
+	return report
+}
+
+// verify checks the signature of the transaction if the pool has a verifier
+// The first return value is true if the transaction has to be quarantined instead of being added to the pool
+func (m *MemPool) verify(tx Transaction) (bool, error) {
+	if m.verifier == nil {
+		return false, nil
+	}
+	if err := m.verifier.VerifySignature(tx); err != nil {
+		if m.verificationPolicy == RejectInvalid {
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// checkBatchReplacement checks the replacement of the transaction read in the atomic mode
+func (m *MemPool) checkBatchReplacement(tx Transaction, batch *replacementBatch) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.checkReplacement(tx, batch)
+}
+
+func (m *MemPool) addToQuarantine(txs ...Transaction) {
+	if len(txs) == 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.quarantine = append(m.quarantine, txs...)
+}
+
+// WriteTransactions writes the transactions from the highest to the lowest priority line by line
+// The pool is not modified
+func (m *MemPool) WriteTransactions(writer io.Writer) error {
+	return m.WriteTransactionsWith(writer, TextCodec{})
+}
+
+// WriteTransactionsWith writes the transactions from the highest to the lowest priority with the codec
+// In the nonce ordering mode they are written in the order they would be popped followed by the queued ones.
+// The parked transactions are written after the poppable ones.
+// The pool is not modified
+func (m *MemPool) WriteTransactionsWith(writer io.Writer, codec Codec) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	encoder := codec.NewEncoder(writer)
+	var err error
+	m.descend(func(e poolEntry) bool {
+		err = encoder.Encode(e.tx)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return encoder.Close()
 }
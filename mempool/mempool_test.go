@@ -2,17 +2,25 @@ package mempool
 
 import (
 	"bytes"
-	"github.com/stretchr/testify/require"
+	"context"
+	"fmt"
+	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMemPool_ReadAndWrite(t *testing.T) {
 	tests := map[string]struct {
-		input string
+		input          string
 		expectedOutput string
-		expectedError string
-	} {
+		expectedError  string
+	}{
 		"valid input": {
 			input: `TxHash=40E10C7CF56A738C0B8AD4EE30EA8008C7B2334B3ADA195083F8CB18BD3911A0 Gas=729000 FeePerGas=0.11134106816568039 Signature=6386A3893BEB6A5A64E0677F406634E791DEE78D49CF30581AE5281D4094E495E671647EF5E7FD2D207AB8EBA0EA693703E9C368402731BE99E81BDB748EA662
 TxHash=4B2B252899DC689106C8FCEA3E24E4AFFC597D2B4E701F99EB8CD909217D323F Gas=834000 FeePerGas=0.27503931836911927 Signature=88B520FC81B8F8D1FD7B1F42B38481426CB5CE7C27F4A03F51C4A4710A0DC5FA3127E3DE20A818555CE74470A3420E39F65FE7D5053FBBC7C2151A3F22081A5B
@@ -43,8 +51,8 @@ TxHash=F75F133F149FDA7DEB391B2446C5196E7C704F45456E69312C310C72893F5B6A Gas=3030
 			expectedOutput: "",
 		},
 		"invalid input": {
-			input: "abcd",
-			expectedError: "Invalid token [abcd]",
+			input:         "abcd",
+			expectedError: "Line 1, column 1: Invalid token [abcd]",
 		},
 	}
 
@@ -67,3 +75,467 @@ TxHash=F75F133F149FDA7DEB391B2446C5196E7C704F45456E69312C310C72893F5B6A Gas=3030
 		})
 	}
 }
+
+func TestMemPool_WriteTransactionsKeepsPool(t *testing.T) {
+	memPool := NewMemPool()
+	for i := 1; i <= 3; i++ {
+		memPool.Push(testTransaction(t, i))
+	}
+
+	var first, second bytes.Buffer
+	require.NoError(t, memPool.WriteTransactions(&first))
+	require.NoError(t, memPool.WriteTransactions(&second))
+
+	require.Equal(t, first.String(), second.String())
+	require.Equal(t, 3, memPool.Len())
+	tx, ok := memPool.Peek()
+	require.True(t, ok)
+	require.Equal(t, testTransaction(t, 3), tx)
+}
+
+func TestMemPool_Remove(t *testing.T) {
+	memPool := NewMemPool()
+	for i := 1; i <= 3; i++ {
+		memPool.Push(testTransaction(t, i))
+	}
+	hash := testTransaction(t, 2).Hash
+
+	require.True(t, memPool.Contains(hash))
+	tx, ok := memPool.Get(hash)
+	require.True(t, ok)
+	require.Equal(t, testTransaction(t, 2), tx)
+
+	tx, ok = memPool.Remove(hash)
+	require.True(t, ok)
+	require.Equal(t, testTransaction(t, 2), tx)
+	require.False(t, memPool.Contains(hash))
+	require.Equal(t, 2, memPool.Len())
+
+	_, ok = memPool.Remove(hash)
+	require.False(t, ok)
+}
+
+func TestMemPool_PushSameHash(t *testing.T) {
+	memPool := NewMemPool()
+	tx := testTransaction(t, 1)
+	memPool.Push(tx)
+	memPool.Push(tx)
+
+	require.Equal(t, 1, memPool.Len())
+}
+
+func TestMemPool_EvictionHook(t *testing.T) {
+	var evictions []Eviction
+	memPool := NewMemPool(WithCapacity(2), WithEvictionHook(func(eviction Eviction) {
+		evictions = append(evictions, eviction)
+	}))
+
+	require.Equal(t, PushResult[Transaction]{Admitted: true}, mustPush(t, memPool, testTransaction(t, 2)))
+	require.Equal(t, PushResult[Transaction]{Admitted: true}, mustPush(t, memPool, testTransaction(t, 3)))
+
+	result := mustPush(t, memPool, testTransaction(t, 1))
+	require.False(t, result.Admitted)
+	require.Nil(t, result.Evicted)
+
+	result = mustPush(t, memPool, testTransaction(t, 4))
+	require.True(t, result.Admitted)
+	require.Equal(t, testTransaction(t, 2), *result.Evicted)
+
+	result = mustPush(t, memPool, testTransaction(t, 4))
+	require.True(t, result.Admitted)
+	require.Equal(t, testTransaction(t, 4), *result.Replaced)
+
+	_, ok := memPool.Remove(testTransaction(t, 3).Hash)
+	require.True(t, ok)
+
+	require.Equal(t, []Eviction{
+		{Transaction: testTransaction(t, 1), Reason: EvictionCapacity},
+		{Transaction: testTransaction(t, 2), Reason: EvictionCapacity},
+		{Transaction: testTransaction(t, 4), Reason: EvictionReplaced},
+		{Transaction: testTransaction(t, 3), Reason: EvictionRemoved},
+	}, evictions)
+}
+
+func TestMemPool_Expire(t *testing.T) {
+	var evictions []Eviction
+	memPool := NewMemPool(WithEvictionHook(func(eviction Eviction) {
+		evictions = append(evictions, eviction)
+	}))
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i <= 4; i++ {
+		memPool.now = func() time.Time {
+			return start.Add(time.Duration(i) * time.Minute)
+		}
+		memPool.Push(testTransaction(t, i))
+	}
+
+	require.Equal(t, 2, memPool.Expire(start.Add(3*time.Minute)))
+	require.Equal(t, 2, memPool.Len())
+	require.False(t, memPool.Contains(testTransaction(t, 1).Hash))
+	require.False(t, memPool.Contains(testTransaction(t, 2).Hash))
+	require.ElementsMatch(t, []Eviction{
+		{Transaction: testTransaction(t, 1), Reason: EvictionExpired},
+		{Transaction: testTransaction(t, 2), Reason: EvictionExpired},
+	}, evictions)
+
+	require.Equal(t, 0, memPool.Expire(start))
+}
+
+func TestMemPool_EvictionHookCanUsePool(t *testing.T) {
+	var memPool *MemPool
+	var lengths []int
+	memPool = NewMemPool(WithCapacity(1), WithEvictionHook(func(eviction Eviction) {
+		lengths = append(lengths, memPool.Len())
+	}))
+	memPool.Push(testTransaction(t, 1))
+	memPool.Push(testTransaction(t, 2))
+
+	require.Equal(t, []int{1}, lengths)
+}
+
+func TestMemPool_TieBreak(t *testing.T) {
+	line := "TxHash=%s Gas=%d FeePerGas=%s Signature=00"
+	newTx := func(hash string, gas int, fee string) Transaction {
+		tx, err := ReadTransaction(fmt.Sprintf(line, hash, gas, fee))
+		require.NoError(t, err)
+		return tx
+	}
+	// All the transactions have the total fee equal to 100 except for the first one
+	input := []Transaction{
+		newTx("E0", 100, "2"),
+		newTx("C0", 100, "1"),
+		newTx("A0", 200, "0.5"),
+		newTx("D0", 50, "2"),
+		newTx("B0", 400, "0.25"),
+	}
+
+	tests := map[string]struct {
+		tieBreak       TieBreak
+		capacity       int
+		expectedHashes []string
+	}{
+		"arrival": {
+			tieBreak:       TieBreakArrival,
+			capacity:       10,
+			expectedHashes: []string{"E0", "C0", "A0", "D0", "B0"},
+		},
+		"hash": {
+			tieBreak:       TieBreakHash,
+			capacity:       10,
+			expectedHashes: []string{"E0", "A0", "B0", "C0", "D0"},
+		},
+		"arrival and hash": {
+			tieBreak:       TieBreakArrival | TieBreakHash,
+			capacity:       10,
+			expectedHashes: []string{"E0", "C0", "A0", "D0", "B0"},
+		},
+		"arrival evicts the latest": {
+			tieBreak:       TieBreakArrival,
+			capacity:       3,
+			expectedHashes: []string{"E0", "C0", "A0"},
+		},
+		"hash evicts the greatest": {
+			tieBreak:       TieBreakHash,
+			capacity:       3,
+			expectedHashes: []string{"E0", "A0", "B0"},
+		},
+	}
+
+	for tName, tc := range tests {
+		tc := tc
+		t.Run(tName, func(t *testing.T) {
+			memPool := NewMemPool(WithCapacity(tc.capacity), WithTieBreak(tc.tieBreak))
+			for _, tx := range input {
+				memPool.Push(tx)
+			}
+
+			var hashes []string
+			for {
+				tx, ok := memPool.Pop()
+				if !ok {
+					break
+				}
+				hashes = append(hashes, tx.Hash)
+			}
+			require.Equal(t, tc.expectedHashes, hashes)
+		})
+	}
+}
+
+func TestMemPool_ExactFeeOrdering(t *testing.T) {
+	// The fees of the pairs are equal when represented as float64
+	lines := []string{
+		"TxHash=A1 Gas=1 FeePerGas=0.1 Signature=00",
+		"TxHash=A2 Gas=1 FeePerGas=0.10000000000000000001 Signature=00",
+		"TxHash=B1 Gas=1000000 FeePerGas=123456789.00000000000001 Signature=00",
+		"TxHash=B2 Gas=1000000 FeePerGas=123456789.00000000000002 Signature=00",
+	}
+	for _, tieBreak := range []TieBreak{TieBreakArrival, TieBreakHash} {
+		memPool := NewMemPool(WithCapacity(3), WithTieBreak(tieBreak))
+		for _, line := range lines {
+			tx, err := ReadTransaction(line)
+			require.NoError(t, err)
+			memPool.Push(tx)
+		}
+
+		var hashes []string
+		for {
+			tx, ok := memPool.Pop()
+			if !ok {
+				break
+			}
+			hashes = append(hashes, tx.Hash)
+		}
+		require.Equal(t, []string{"B2", "B1", "A2"}, hashes)
+	}
+}
+
+func TestMemPool_ReadTransactionsWithSignatureVerifier(t *testing.T) {
+	registry := readTestKeyRegistry(t)
+	valid, err := os.ReadFile("testdata/signed-transactions.txt")
+	require.NoError(t, err)
+	invalid, err := os.ReadFile("testdata/invalid-signed-transactions.txt")
+	require.NoError(t, err)
+
+	memPool := NewMemPool(WithSignatureVerifier(registry, RejectInvalid))
+	require.NoError(t, memPool.ReadTransactions(bytes.NewReader(valid)))
+	require.Equal(t, 8, memPool.Len())
+	err = memPool.ReadTransactions(bytes.NewReader(invalid))
+	require.ErrorIs(t, err, ErrInvalidSignature)
+	require.Equal(t, 8, memPool.Len())
+
+	memPool = NewMemPool(WithSignatureVerifier(registry, QuarantineInvalid))
+	input := append(append(append([]byte{}, valid...), '\n'), invalid...)
+	require.NoError(t, memPool.ReadTransactions(bytes.NewReader(input)))
+	require.Equal(t, 8, memPool.Len())
+	require.Equal(t, readTestTransactions(t, "testdata/invalid-signed-transactions.txt"), memPool.Quarantined())
+}
+
+func TestMemPool_ReadTransactionsVerifyHash(t *testing.T) {
+	input := `TxHash=06577999BDDA0C57752478369A5D207FBD0A2AD88CF5D204F1A3E1C840E60358 Gas=729000 FeePerGas=0.11134106816568039 Signature=CD
+TxHash=06577999BDDA0C57752478369A5D207FBD0A2AD88CF5D204F1A3E1C840E60358 Gas=729001 FeePerGas=0.11134106816568039 Signature=CD`
+
+	memPool := NewMemPool(WithParseOptions(ParseOptions{VerifyHash: true}))
+	err := memPool.ReadTransactions(strings.NewReader(input))
+	require.ErrorIs(t, err, ErrHashMismatch)
+	require.Equal(t, 1, memPool.Len())
+}
+
+func TestMemPool_HashCaseIsIgnored(t *testing.T) {
+	memPool := NewMemPool()
+	tx := testTransaction(t, 10)
+	memPool.Push(tx)
+
+	lower := tx
+	lower.Hash = strings.ToLower(tx.Hash)
+	require.True(t, memPool.Contains(lower.Hash))
+
+	result := mustPush(t, memPool, lower)
+	require.Equal(t, tx, *result.Replaced)
+	require.Equal(t, 1, memPool.Len())
+
+	_, ok := memPool.Remove(tx.Hash)
+	require.True(t, ok)
+}
+
+func testTransaction(t *testing.T, n int) Transaction {
+	line := fmt.Sprintf("TxHash=%064X Gas=1000 FeePerGas=%d Signature=%0128X", n, n, n)
+	tx, err := ReadTransaction(line)
+	require.NoError(t, err)
+	return tx
+}
+
+// mustPush pushes the transaction to the pool, which must not fail
+func mustPush(t *testing.T, memPool *MemPool, tx Transaction) PushResult[Transaction] {
+	result, err := memPool.Push(tx)
+	require.NoError(t, err)
+	return result
+}
+
+func TestMemPool_PushAndPop(t *testing.T) {
+	memPool := NewMemPool(WithCapacity(3))
+	for i := 1; i <= 5; i++ {
+		memPool.Push(testTransaction(t, i))
+	}
+	require.Equal(t, 3, memPool.Len())
+
+	for i := 5; i >= 3; i-- {
+		tx, ok := memPool.Pop()
+		require.True(t, ok)
+		require.Equal(t, testTransaction(t, i), tx)
+	}
+
+	_, ok := memPool.Pop()
+	require.False(t, ok)
+}
+
+func TestMemPool_PopWait(t *testing.T) {
+	memPool := NewMemPool()
+
+	result := make(chan Transaction)
+	go func() {
+		tx, err := memPool.PopWait(context.Background())
+		assert.NoError(t, err)
+		result <- tx
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	memPool.Push(testTransaction(t, 1))
+
+	select {
+	case tx := <-result:
+		require.Equal(t, testTransaction(t, 1), tx)
+	case <-time.After(time.Second):
+		t.Fatal("PopWait did not return after a transaction was pushed")
+	}
+}
+
+func TestMemPool_PopWaitCancelled(t *testing.T) {
+	memPool := NewMemPool()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := memPool.PopWait(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestMemPool_ConcurrentPushAndPopWait(t *testing.T) {
+	const (
+		producers   = 8
+		consumers   = 4
+		perProducer = 500
+	)
+	memPool := NewMemPool()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var popped sync.Map
+	var poppedCount int64
+	var consumersWg sync.WaitGroup
+	for c := 0; c < consumers; c++ {
+		consumersWg.Add(1)
+		go func() {
+			defer consumersWg.Done()
+			for {
+				tx, err := memPool.PopWait(ctx)
+				if err != nil {
+					return
+				}
+				_, loaded := popped.LoadOrStore(tx.Hash, struct{}{})
+				assert.False(t, loaded, "transaction %s popped twice", tx.Hash)
+				atomic.AddInt64(&poppedCount, 1)
+			}
+		}()
+	}
+
+	// The fixtures are built up front, as the producers must not fail the test
+	txs := make([]Transaction, producers*perProducer)
+	for i := range txs {
+		txs[i] = testTransaction(t, i+1)
+	}
+	var producersWg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		producersWg.Add(1)
+		go func(p int) {
+			defer producersWg.Done()
+			for i := 0; i < perProducer; i++ {
+				memPool.Push(txs[p*perProducer+i])
+			}
+		}(p)
+	}
+	producersWg.Wait()
+
+	require.Eventually(t, func() bool {
+		return memPool.Len() == 0
+	}, 5*time.Second, time.Millisecond)
+	cancel()
+	consumersWg.Wait()
+
+	require.Equal(t, int64(producers*perProducer), atomic.LoadInt64(&poppedCount))
+}
+
+func TestMemPool_ConcurrentCapacityEviction(t *testing.T) {
+	const (
+		capacity    = 50
+		producers   = 8
+		perProducer = 200
+	)
+	memPool := NewMemPool(WithCapacity(capacity))
+
+	// The fixtures are built up front, as the producers must not fail the test
+	txs := make([]Transaction, producers*perProducer)
+	for i := range txs {
+		txs[i] = testTransaction(t, i+1)
+	}
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(2)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				memPool.Push(txs[p*perProducer+i])
+				assert.LessOrEqual(t, memPool.Len(), capacity)
+			}
+		}(p)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perProducer/4; i++ {
+				memPool.Pop()
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.LessOrEqual(t, memPool.Len(), capacity)
+	var previous *Transaction
+	for {
+		tx, ok := memPool.Pop()
+		if !ok {
+			break
+		}
+		if previous != nil {
+			require.LessOrEqual(t, tx.Priority(), previous.Priority())
+		}
+		previous = &tx
+	}
+}
+
+func TestMemPool_PushBatchMatchesPush(t *testing.T) {
+	file, err := os.Open("../transactions.txt")
+	require.NoError(t, err)
+	defer file.Close()
+
+	batchPool := NewMemPool(WithTieBreak(TieBreakArrival))
+	_, err = batchPool.ReadTransactionsWith(file, ReadOptions{Atomic: true})
+	require.NoError(t, err)
+
+	_, err = file.Seek(0, 0)
+	require.NoError(t, err)
+	pushPool := NewMemPool(WithTieBreak(TieBreakArrival))
+	require.NoError(t, pushPool.ReadTransactions(file))
+
+	var batchOutput, pushOutput bytes.Buffer
+	require.NoError(t, batchPool.WriteTransactions(&batchOutput))
+	require.NoError(t, pushPool.WriteTransactions(&pushOutput))
+	require.Equal(t, memPoolCapacity, batchPool.Len())
+	require.Equal(t, pushOutput.String(), batchOutput.String())
+}
+
+func TestMemPool_PushBatch(t *testing.T) {
+	var evictions []Eviction
+	memPool := NewMemPool(WithCapacity(2), WithEvictionHook(func(eviction Eviction) {
+		evictions = append(evictions, eviction)
+	}))
+	memPool.Push(testTransaction(t, 3))
+
+	memPool.PushBatch([]Transaction{testTransaction(t, 1), testTransaction(t, 4), testTransaction(t, 3)})
+	require.Equal(t, 2, memPool.Len())
+	require.Equal(t, []Eviction{
+		{Transaction: testTransaction(t, 3), Reason: EvictionReplaced},
+		{Transaction: testTransaction(t, 1), Reason: EvictionCapacity},
+	}, evictions)
+
+	tx, ok := memPool.Pop()
+	require.True(t, ok)
+	require.Equal(t, testTransaction(t, 4), tx)
+}
@@ -0,0 +1,333 @@
+package mempool
+
+import (
+	"math"
+	"sort"
+)
+
+// account keeps the transactions of a sender in the nonce ordering mode
+type account struct {
+	// next is the nonce of the sender which is expected to be popped next. It is known once a transaction of the
+	// sender is popped or the nonce is set with SetNonce, until then the lowest nonce in the pool is the next one
+	next      uint64
+	nextKnown bool
+	// txs are all the transactions of the sender in the pool by their nonces
+	txs map[uint64]poolEntry
+	// head is the key of the transaction of the sender which is in the queue or in the parked set of the pool,
+	// empty if there is none
+	head string
+}
+
+// start returns the nonce the pending transactions of the account start with
+func (a *account) start() uint64 {
+	if a.nextKnown {
+		return a.next
+	}
+	start, first := a.next, true
+	for nonce := range a.txs {
+		if first || nonce < start {
+			start, first = nonce, false
+		}
+	}
+	return start
+}
+
+// senderNonce locates a transaction of an account
+type senderNonce struct {
+	sender string
+	nonce  uint64
+}
+
+// ordered reports whether the transaction is ordered by its nonce, i.e. the pool is in the nonce ordering mode
+// and the transaction has both the sender and the nonce
+func (m *MemPool) ordered(tx Transaction) bool {
+	return m.nonceOrdering && tx.Sender != "" && tx.HasNonce
+}
+
+// unorderedNonce returns the sender and the nonce of the transaction which is not ordered by its nonce, as the
+// pool is not in the nonce ordering mode. The second return value is false if it lacks either of them
+func (m *MemPool) unorderedNonce(tx Transaction) (senderNonce, bool) {
+	return senderNonce{sender: tx.Sender, nonce: tx.Nonce}, !m.nonceOrdering && tx.Sender != "" && tx.HasNonce
+}
+
+// forgetNonce removes the entry which leaves the pool from the index of the unordered senders and nonces
+func (m *MemPool) forgetNonce(e poolEntry) {
+	if sn, ok := m.unorderedNonce(e.tx); ok && m.senderNonces[sn] == entryKey(e) {
+		delete(m.senderNonces, sn)
+	}
+}
+
+// hasSenderNonce reports whether any of the transactions has both the sender and the nonce
+func hasSenderNonce(txs []Transaction) bool {
+	for _, tx := range txs {
+		if tx.Sender != "" && tx.HasNonce {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MemPool) account(sender string) *account {
+	a, ok := m.accounts[sender]
+	if !ok {
+		a = &account{txs: make(map[uint64]poolEntry)}
+		m.accounts[sender] = a
+	}
+	return a
+}
+
+// SetNonce sets the nonce of the sender which is expected to be popped next, e.g. once the transactions of the
+// sender are mined. The transactions of the sender with lower nonces are removed from the pool as stale.
+// It has no effect unless the pool is in the nonce ordering mode
+func (m *MemPool) SetNonce(sender string, nonce uint64) {
+	if !m.nonceOrdering {
+		return
+	}
+
+	m.mu.Lock()
+	a := m.account(sender)
+	a.next, a.nextKnown = nonce, true
+	var stale []poolEntry
+	for n, e := range a.txs {
+		if n < nonce {
+			stale = append(stale, e)
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool {
+		return stale[i].tx.Nonce < stale[j].tx.Nonce
+	})
+	evictions := make([]Eviction, 0, len(stale))
+	for _, e := range stale {
+		key := normalizeHash(e.tx.Hash)
+		delete(a.txs, e.tx.Nonce)
+		delete(m.nonces, key)
+		m.queued.Remove(key)
+		evictions = append(evictions, Eviction{Transaction: e.tx, Reason: EvictionStale})
+	}
+	m.reorganize(sender)
+	if m.queue.Len() > 0 {
+		m.wakeConsumers()
+	}
+	m.mu.Unlock()
+
+	m.notifyEvictions(evictions)
+}
+
+// QueuedLen returns the number of the transactions which wait for a missing nonce of their senders
+func (m *MemPool) QueuedLen() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.queued.Len()
+}
+
+// getEntry returns the entry with the given key wherever it is kept
+func (m *MemPool) getEntry(key string) (poolEntry, bool) {
+	if sn, ok := m.nonces[key]; ok {
+		return m.accounts[sn.sender].txs[sn.nonce], true
+	}
+	if e, ok := m.queue.Get(key); ok {
+		return e, true
+	}
+	return m.parked.Get(key)
+}
+
+// removeEntry removes the entry with the given key wherever it is kept
+// Removing a transaction of an account makes its following transactions queued if the next nonce of the sender
+// is known, otherwise the next transaction of the sender becomes poppable
+func (m *MemPool) removeEntry(key string) (poolEntry, bool) {
+	sn, ok := m.nonces[key]
+	if !ok {
+		return m.unplace(key)
+	}
+
+	a := m.accounts[sn.sender]
+	e := a.txs[sn.nonce]
+	delete(a.txs, sn.nonce)
+	delete(m.nonces, key)
+	m.queued.Remove(key)
+	m.reorganize(sn.sender)
+	return e, true
+}
+
+// pop removes the transaction with the highest priority from the queue
+// If it belongs to an account, the next nonce of the sender becomes known and the following transaction poppable
+func (m *MemPool) pop() poolEntry {
+	e := m.queue.Pop()
+	m.popped(e)
+	return e
+}
+
+// popped updates the account of the entry which is removed from the queue as it is popped or mined
+func (m *MemPool) popped(e poolEntry) {
+	m.forgetNonce(e)
+	key := entryKey(e)
+	sn, ok := m.nonces[key]
+	if !ok {
+		return
+	}
+
+	a := m.accounts[sn.sender]
+	delete(a.txs, sn.nonce)
+	delete(m.nonces, key)
+	a.head = ""
+	m.heads--
+	a.next, a.nextKnown = sn.nonce+1, true
+	m.reorganize(sn.sender)
+}
+
+// reorganize places the transactions of the sender after a change of the account: the transaction with the next
+// nonce is in the queue or in the parked set of the pool, the ones following it without a gap are pending aside of the queue and the
+// rest are in the queued sub-pool
+func (m *MemPool) reorganize(sender string) {
+	a := m.accounts[sender]
+	start := a.start()
+	head := ""
+	if e, ok := a.txs[start]; ok {
+		head = normalizeHash(e.tx.Hash)
+	}
+	if a.head != head {
+		if a.head != "" {
+			m.unplace(a.head)
+			m.heads--
+		}
+		if head != "" {
+			m.queued.Remove(head)
+			m.place(a.txs[start])
+			m.heads++
+		}
+		a.head = head
+	}
+
+	var pending uint64
+	for pending < uint64(len(a.txs)) {
+		if _, ok := a.txs[start+pending]; !ok {
+			break
+		}
+		pending++
+	}
+	for nonce, e := range a.txs {
+		key := normalizeHash(e.tx.Hash)
+		if nonce-start < pending {
+			m.queued.Remove(key)
+		} else if !m.queued.Contains(key) {
+			m.queued.Push(e)
+		}
+	}
+
+	// The account is kept while its next nonce is known, so the stale transactions are recognized
+	if len(a.txs) == 0 && !a.nextKnown {
+		delete(m.accounts, sender)
+	}
+}
+
+// len returns the number of the transactions in the pool, including the ones of the accounts aside of the queue
+func (m *MemPool) len() int {
+	return m.queue.Len() + m.parked.Len() - m.heads + len(m.nonces)
+}
+
+// descend calls fn for the transactions in the order they would be popped until fn returns false
+// The parked transactions follow in the order they would be popped if the base fee dropped, and then the queued
+// transactions, which can't be popped, from the highest to the lowest priority
+func (m *MemPool) descend(fn func(e poolEntry) bool) {
+	if len(m.nonces) == 0 && m.parked.Len() == 0 {
+		m.queue.Descend(fn)
+		return
+	}
+
+	poppable, parked := m.snapshot(m.queue), m.snapshot(m.parked)
+	for _, q := range []*PriorityQueueOf[poolEntry]{&poppable, &parked} {
+		for q.Len() > 0 {
+			e := q.Pop()
+			if !fn(e) {
+				return
+			}
+			next, ok := m.follower(e)
+			if !ok {
+				continue
+			}
+			// The transaction following a parked one stays parked, as it can't be popped before it
+			if q == &poppable && !m.parks(next.tx) {
+				poppable.Push(next)
+			} else {
+				parked.Push(next)
+			}
+		}
+	}
+	m.queued.Descend(fn)
+}
+
+// walkPoppable calls fn for the poppable transactions in the order they would be popped without modifying the pool
+// The first result of fn tells whether the transaction is taken, only the followers of the taken transactions are
+// visited, unless they are parked. The second one tells whether to go on
+func (m *MemPool) walkPoppable(fn func(e poolEntry) (bool, bool)) {
+	// followers are the followers of the taken transactions which are not visited yet, merged into the walk of the queue
+	followers := NewPriorityQueueFunc(math.MaxInt, m.less)
+	visit := func(e poolEntry) bool {
+		taken, more := fn(e)
+		if !taken {
+			return more
+		}
+		if next, ok := m.follower(e); ok && !m.parks(next.tx) {
+			followers.Push(next)
+		}
+		return more
+	}
+
+	more := true
+	m.queue.Descend(func(e poolEntry) bool {
+		for more && followers.Len() > 0 {
+			if next, _ := followers.Peek(); !m.less(e, next) {
+				break
+			}
+			more = visit(followers.Pop())
+		}
+		if more {
+			more = visit(e)
+		}
+		return more
+	})
+	for more && followers.Len() > 0 {
+		more = visit(followers.Pop())
+	}
+}
+
+// snapshot copies the entries of the queue to a priority queue which can be modified
+func (m *MemPool) snapshot(q *KeyedPriorityQueue[string, poolEntry]) PriorityQueueOf[poolEntry] {
+	entries := make([]poolEntry, 0, q.Len())
+	q.Descend(func(e poolEntry) bool {
+		entries = append(entries, e)
+		return true
+	})
+	snapshot := NewPriorityQueueFunc(math.MaxInt, m.less)
+	snapshot.PushBatch(entries)
+	return snapshot
+}
+
+// follower returns the pending transaction of the account which follows the entry, if any
+func (m *MemPool) follower(e poolEntry) (poolEntry, bool) {
+	sn, ok := m.nonces[entryKey(e)]
+	if !ok || sn.nonce == math.MaxUint64 {
+		return poolEntry{}, false
+	}
+	next, ok := m.accounts[sn.sender].txs[sn.nonce+1]
+	if !ok || m.queued.Contains(entryKey(next)) {
+		return poolEntry{}, false
+	}
+	return next, true
+}
+
+// forEach calls fn for all the transactions of the pool in no particular order
+func (m *MemPool) forEach(fn func(e poolEntry)) {
+	visit := func(e poolEntry) bool {
+		fn(e)
+		return true
+	}
+	m.queue.Ascend(visit)
+	m.parked.Ascend(visit)
+	for key, sn := range m.nonces {
+		if key != m.accounts[sn.sender].head {
+			fn(m.accounts[sn.sender].txs[sn.nonce])
+		}
+	}
+}
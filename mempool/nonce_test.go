@@ -0,0 +1,227 @@
+package mempool
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func nonceTransaction(t *testing.T, sender string, nonce uint64, fee int) Transaction {
+	line := fmt.Sprintf("TxHash=%s%d-%d Gas=1 FeePerGas=%d Signature=CD Sender=%s Nonce=%d", sender, nonce, fee, fee, sender, nonce)
+	tx, err := ReadTransaction(line)
+	require.NoError(t, err)
+	return tx
+}
+
+func popAll(memPool *MemPool) []string {
+	var hashes []string
+	for {
+		tx, ok := memPool.Pop()
+		if !ok {
+			return hashes
+		}
+		hashes = append(hashes, tx.Hash)
+	}
+}
+
+func TestMemPool_NonceOrdering(t *testing.T) {
+	tests := map[string]struct {
+		txs            []Transaction
+		expectedOrder  []string
+		expectedQueued int
+	}{
+		"senders are ordered by priority of the next nonce": {
+			txs: []Transaction{
+				nonceTransaction(t, "alice", 1, 10),
+				nonceTransaction(t, "alice", 0, 1),
+				nonceTransaction(t, "bob", 0, 5),
+				{Hash: "carol", Gas: 1, FeePerGas: "3", Signature: "CD"},
+			},
+			expectedOrder: []string{"bob0-5", "carol", "alice0-1", "alice1-10"},
+		},
+		"nonce gap": {
+			txs: []Transaction{
+				nonceTransaction(t, "alice", 0, 1),
+				nonceTransaction(t, "alice", 2, 3),
+				nonceTransaction(t, "bob", 4, 2),
+			},
+			expectedOrder:  []string{"bob4-2", "alice0-1"},
+			expectedQueued: 1,
+		},
+		"transactions without nonce are not ordered": {
+			txs: []Transaction{
+				nonceTransaction(t, "alice", 0, 1),
+				{Hash: "alice-2", Gas: 1, FeePerGas: "2", Signature: "CD", Sender: "alice"},
+			},
+			expectedOrder: []string{"alice-2", "alice0-1"},
+		},
+	}
+
+	for tName, tc := range tests {
+		tc := tc
+		t.Run(tName, func(t *testing.T) {
+			memPool := NewMemPool(WithNonceOrdering(), WithTieBreak(TieBreakArrival))
+			for _, tx := range tc.txs {
+				require.True(t, mustPush(t, memPool, tx).Admitted)
+			}
+			require.Equal(t, len(tc.txs), memPool.Len())
+
+			var output bytes.Buffer
+			require.NoError(t, memPool.WriteTransactions(&output))
+			lines := strings.Split(output.String(), "\n")
+			require.Len(t, lines, len(tc.txs))
+
+			require.Equal(t, tc.expectedOrder, popAll(memPool))
+			require.Equal(t, tc.expectedQueued, memPool.QueuedLen())
+			require.Equal(t, tc.expectedQueued, memPool.Len())
+			for i, hash := range tc.expectedOrder {
+				require.True(t, strings.HasPrefix(lines[i], "TxHash="+hash+" "))
+			}
+		})
+	}
+}
+
+func TestMemPool_NonceGapIsFilled(t *testing.T) {
+	memPool := NewMemPool(WithNonceOrdering())
+	memPool.Push(nonceTransaction(t, "alice", 5, 1))
+	memPool.Push(nonceTransaction(t, "alice", 7, 3))
+	memPool.Push(nonceTransaction(t, "alice", 8, 4))
+
+	require.Equal(t, []string{"alice5-1"}, popAll(memPool))
+	require.Equal(t, 2, memPool.QueuedLen())
+	require.True(t, memPool.Contains("alice8-4"))
+
+	memPool.Push(nonceTransaction(t, "alice", 6, 2))
+	require.Equal(t, 0, memPool.QueuedLen())
+	require.Equal(t, []string{"alice6-2", "alice7-3", "alice8-4"}, popAll(memPool))
+}
+
+func TestMemPool_NonceOrderingLowerNonceArrivesLater(t *testing.T) {
+	memPool := NewMemPool(WithNonceOrdering())
+	memPool.Push(nonceTransaction(t, "alice", 5, 1))
+	memPool.Push(nonceTransaction(t, "alice", 4, 1))
+
+	require.Equal(t, []string{"alice4-1", "alice5-1"}, popAll(memPool))
+}
+
+func TestMemPool_NonceOrderingStale(t *testing.T) {
+	var evictions []Eviction
+	memPool := NewMemPool(WithNonceOrdering(), WithEvictionHook(func(eviction Eviction) {
+		evictions = append(evictions, eviction)
+	}))
+	memPool.Push(nonceTransaction(t, "alice", 0, 1))
+	memPool.Push(nonceTransaction(t, "alice", 1, 1))
+	memPool.Pop()
+
+	stale := nonceTransaction(t, "alice", 0, 2)
+	require.False(t, mustPush(t, memPool, stale).Admitted)
+	require.Equal(t, []Eviction{{Transaction: stale, Reason: EvictionStale}}, evictions)
+
+	memPool.SetNonce("alice", 3)
+	require.Equal(t, EvictionStale, evictions[1].Reason)
+	require.Equal(t, "alice1-1", evictions[1].Transaction.Hash)
+	require.Equal(t, 0, memPool.Len())
+}
+
+func TestMemPool_SetNonce(t *testing.T) {
+	memPool := NewMemPool(WithNonceOrdering())
+	memPool.Push(nonceTransaction(t, "alice", 3, 1))
+	memPool.Push(nonceTransaction(t, "alice", 4, 1))
+
+	memPool.SetNonce("alice", 2)
+	_, ok := memPool.Pop()
+	require.False(t, ok)
+	require.Equal(t, 2, memPool.QueuedLen())
+
+	memPool.SetNonce("alice", 4)
+	require.Equal(t, 1, memPool.Len())
+	require.Equal(t, []string{"alice4-1"}, popAll(memPool))
+}
+
+func TestMemPool_NonceOrderingReplace(t *testing.T) {
+	memPool := NewMemPool(WithNonceOrdering())
+	first := nonceTransaction(t, "alice", 0, 1)
+	memPool.Push(first)
+	memPool.Push(nonceTransaction(t, "alice", 1, 1))
+
+	result := mustPush(t, memPool, nonceTransaction(t, "alice", 0, 2))
+	require.True(t, result.Admitted)
+	require.Equal(t, &first, result.Replaced)
+	require.Equal(t, 2, memPool.Len())
+	require.Equal(t, []string{"alice0-2", "alice1-1"}, popAll(memPool))
+}
+
+func TestMemPool_NonceOrderingRemove(t *testing.T) {
+	memPool := NewMemPool(WithNonceOrdering())
+	memPool.Push(nonceTransaction(t, "alice", 0, 1))
+	memPool.Push(nonceTransaction(t, "alice", 1, 1))
+	memPool.Push(nonceTransaction(t, "alice", 2, 1))
+
+	tx, ok := memPool.Get("ALICE1-1")
+	require.True(t, ok)
+	require.Equal(t, uint64(1), tx.Nonce)
+
+	_, ok = memPool.Remove("alice1-1")
+	require.True(t, ok)
+	require.False(t, memPool.Contains("alice1-1"))
+	require.Equal(t, []string{"alice0-1"}, popAll(memPool))
+	require.Equal(t, 1, memPool.QueuedLen())
+}
+
+func TestMemPool_NonceOrderingCapacityEvictsQueuedFirst(t *testing.T) {
+	var evictions []Eviction
+	memPool := NewMemPool(WithNonceOrdering(), WithCapacity(3), WithEvictionHook(func(eviction Eviction) {
+		evictions = append(evictions, eviction)
+	}))
+	memPool.Push(nonceTransaction(t, "alice", 0, 5))
+	queued := nonceTransaction(t, "alice", 2, 100)
+	memPool.Push(queued)
+	memPool.Push(testTransaction(t, 1))
+
+	result := mustPush(t, memPool, testTransaction(t, 2))
+	require.True(t, result.Admitted)
+	require.Equal(t, &queued, result.Evicted)
+	require.Equal(t, []Eviction{{Transaction: queued, Reason: EvictionCapacity}}, evictions)
+
+	lowest := nonceTransaction(t, "alice", 0, 5)
+	result = mustPush(t, memPool, nonceTransaction(t, "bob", 0, 7))
+	require.Equal(t, &lowest, result.Evicted)
+	require.False(t, mustPush(t, memPool, testTransaction(t, 0)).Admitted)
+	require.Equal(t, 3, memPool.Len())
+}
+
+func TestMemPool_NonceOrderingExpire(t *testing.T) {
+	now := time.Unix(1000, 0)
+	memPool := NewMemPool(WithNonceOrdering())
+	memPool.now = func() time.Time {
+		return now
+	}
+	memPool.Push(nonceTransaction(t, "alice", 0, 1))
+	memPool.Push(nonceTransaction(t, "alice", 1, 1))
+	memPool.Push(nonceTransaction(t, "alice", 3, 1))
+	now = now.Add(time.Minute)
+	memPool.Push(nonceTransaction(t, "bob", 0, 1))
+
+	require.Equal(t, 3, memPool.Expire(now))
+	require.Equal(t, 1, memPool.Len())
+	require.Equal(t, 0, memPool.QueuedLen())
+}
+
+func TestMemPool_NonceOrderingWithoutSenders(t *testing.T) {
+	input, err := os.ReadFile("../transactions.txt")
+	require.NoError(t, err)
+
+	var expected, actual bytes.Buffer
+	memPool := NewMemPool(WithTieBreak(TieBreakArrival))
+	require.NoError(t, memPool.ReadTransactions(bytes.NewReader(input)))
+	require.NoError(t, memPool.WriteTransactions(&expected))
+	memPool = NewMemPool(WithTieBreak(TieBreakArrival), WithNonceOrdering())
+	require.NoError(t, memPool.ReadTransactions(bytes.NewReader(input)))
+	require.NoError(t, memPool.WriteTransactions(&actual))
+	require.Equal(t, expected.String(), actual.String())
+}
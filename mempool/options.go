@@ -0,0 +1,84 @@
+package mempool
+
+import "math/big"
+
+// TieBreak is a set of rules ordering the transactions with equal priority
+// The rules are applied in the order of declaration and define both the output order and the eviction order
+type TieBreak int
+
+const (
+	// TieBreakArrival puts the transaction which arrived earlier first and evicts the later one first
+	TieBreakArrival TieBreak = 1 << iota
+	// TieBreakHash puts the transaction with the lexicographically smaller hash first
+	TieBreakHash
+)
+
+// Option configures a MemPool created by NewMemPool
+type Option func(m *MemPool)
+
+// WithCapacity sets the maximum number of transactions kept in the pool
+func WithCapacity(capacity int) Option {
+	return func(m *MemPool) {
+		m.capacity = capacity
+	}
+}
+
+// WithEvictionHook sets the function which is called for every transaction dropped from the pool
+// The hook is called after the pool is unlocked, so it may call the methods of the pool
+func WithEvictionHook(hook func(eviction Eviction)) Option {
+	return func(m *MemPool) {
+		m.evictionHook = hook
+	}
+}
+
+// WithTieBreak makes the order of the transactions with equal priority deterministic
+// Without it, such transactions are ordered depending on the state of the heap
+func WithTieBreak(tieBreak TieBreak) Option {
+	return func(m *MemPool) {
+		m.tieBreak = tieBreak
+	}
+}
+
+// WithSignatureVerifier makes ReadTransactions verify the signatures of the transactions
+// The policy defines whether a transaction with an invalid signature fails the reading or is quarantined
+func WithSignatureVerifier(verifier SignatureVerifier, policy VerificationPolicy) Option {
+	return func(m *MemPool) {
+		m.verifier = verifier
+		m.verificationPolicy = policy
+	}
+}
+
+// WithNonceOrdering makes the transactions with a sender and a nonce poppable only in the order of their nonces
+// Only the transaction with the next nonce of every sender competes by priority with the other transactions, the
+// following ones wait until it is popped. The transactions after a nonce gap are queued until the gap is filled and
+// are evicted first when the capacity is surpassed
+func WithNonceOrdering() Option {
+	return func(m *MemPool) {
+		m.nonceOrdering = true
+	}
+}
+
+// WithReplaceByFee makes a transaction replace the one it conflicts with only if its total fee is higher by at least
+// the bump percentage, otherwise it is rejected with ErrReplacementUnderpriced. The transactions conflict if they
+// have the same hash or the same sender and nonce.
+// Without it, a conflicting transaction always replaces the one in the pool
+func WithReplaceByFee(bumpPercent int) Option {
+	return func(m *MemPool) {
+		m.replaceByFee = true
+		m.replaceBump = bumpPercent
+	}
+}
+
+// WithBaseFee sets the initial base fee per gas of the pool, see SetBaseFee
+func WithBaseFee(baseFee *big.Rat) Option {
+	return func(m *MemPool) {
+		m.baseFee = copyRat(baseFee)
+	}
+}
+
+// WithParseOptions sets the validations applied by ReadTransactions to every transaction
+func WithParseOptions(options ParseOptions) Option {
+	return func(m *MemPool) {
+		m.parseOptions = options
+	}
+}
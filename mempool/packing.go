@@ -0,0 +1,330 @@
+package mempool
+
+import (
+	"math"
+	"math/big"
+)
+
+// PackingStrategy defines how BuildBlockWith selects the transactions of a block
+type PackingStrategy int
+
+const (
+	// PackGreedy selects the transactions from the highest to the lowest priority, see BuildBlock
+	PackGreedy PackingStrategy = iota
+	// PackOptimal selects the transactions with the highest total fee which fit the gas limit. Small pools are packed
+	// exactly by dynamic programming, the larger ones by fee per gas with local improvement
+	PackOptimal
+)
+
+// exactPackingCells limits the size of the table of the exact packing, i.e. the number of the candidate transactions
+// times the gas limit in units of the greatest common divisor of their gas, the larger pools are packed approximately
+const exactPackingCells = 1 << 22
+
+// maxImprovementRounds limits the number of the moves of the local improvement of the approximate packing
+const maxImprovementRounds = 1000
+
+// packingChain is a poppable transaction followed by the pending transactions of its sender, if any
+// Only a prefix of a chain can be selected, as a transaction can't be included before the preceding one
+type packingChain []poolEntry
+
+// packingItem is a transaction of a chain
+type packingItem struct {
+	entry poolEntry
+	// density is the fee per gas of the transaction, nil if it has no gas
+	density *big.Rat
+	chain   int
+	index   int
+}
+
+func newPackingItem(chains []packingChain, chain int, index int) packingItem {
+	item := packingItem{entry: chains[chain][index], chain: chain, index: index}
+	if gas := item.entry.tx.Gas; gas != 0 {
+		item.density = new(big.Rat).Quo(item.entry.priority, new(big.Rat).SetInt64(int64(gas)))
+	}
+	return item
+}
+
+// pack selects the transactions for a block by the strategy and returns them in the order they would be popped
+func (m *MemPool) pack(gasLimit int, strategy PackingStrategy) []poolEntry {
+	if strategy == PackOptimal {
+		return m.blockEntries(m.packOptimal(gasLimit))
+	}
+	return m.packGreedy(gasLimit)
+}
+
+// packGreedy selects the transactions from the highest to the lowest priority skipping the ones which don't fit
+// the remaining gas. It returns the selected transactions in the order they would be popped and stops walking the
+// pool once the gas limit is reached or the remaining gas is below the gas of every transaction
+func (m *MemPool) packGreedy(gasLimit int) []poolEntry {
+	minGas := m.minGas()
+	var entries []poolEntry
+	gas := 0
+	m.walkPoppable(func(e poolEntry) (bool, bool) {
+		if gas >= gasLimit || gasLimit-gas < minGas {
+			return false, false
+		}
+		// The following transactions of the sender of a skipped transaction can't be selected either
+		if e.tx.Gas > gasLimit-gas {
+			return false, true
+		}
+		entries = append(entries, e)
+		gas += e.tx.Gas
+		return true, true
+	})
+	return entries
+}
+
+// minGas returns the lowest gas of the transactions of the pool, math.MaxInt if the pool is empty
+func (m *MemPool) minGas() int {
+	gas := math.MaxInt
+	visit := func(e poolEntry) {
+		if e.tx.Gas < gas {
+			gas = e.tx.Gas
+		}
+	}
+	m.queue.each(visit)
+	m.parked.each(visit)
+	m.queued.each(visit)
+	for _, a := range m.accounts {
+		for _, e := range a.txs {
+			visit(e)
+		}
+	}
+	return gas
+}
+
+// packOptimal selects the transactions with the highest total fee which fit the gas limit
+// The pool is packed exactly if the table of the dynamic programming is small enough, otherwise approximately
+func (m *MemPool) packOptimal(gasLimit int) map[string]bool {
+	chains := m.packingChains()
+	if selected, ok := packExact(chains, gasLimit); ok {
+		return selected
+	}
+	return packApproximate(chains, gasLimit)
+}
+
+// packingChains returns the chains starting with the poppable transactions
+// The chain ends before the first parked transaction and the first one with a negative gas, which are never selected
+func (m *MemPool) packingChains() []packingChain {
+	chains := make([]packingChain, 0, m.queue.Len())
+	m.queue.Ascend(func(e poolEntry) bool {
+		var chain packingChain
+		for ok := true; ok; e, ok = m.follower(e) {
+			if e.tx.Gas < 0 || (len(chain) > 0 && m.parks(e.tx)) {
+				break
+			}
+			chain = append(chain, e)
+		}
+		if len(chain) > 0 {
+			chains = append(chains, chain)
+		}
+		return true
+	})
+	return chains
+}
+
+// packExact solves the knapsack problem of the chains, choosing a prefix of every chain, by dynamic programming
+// over the gas in units of the greatest common divisor of the gas of the transactions.
+// The second return value is false if the table is too large
+func packExact(chains []packingChain, gasLimit int) (map[string]bool, bool) {
+	unit := 0
+	denom := big.NewInt(1)
+	for _, chain := range chains {
+		for _, e := range chain {
+			unit = gcd(unit, e.tx.Gas)
+			denom = lcm(denom, e.priority.Denom())
+		}
+	}
+	if unit == 0 {
+		unit = 1
+	}
+	capacity := 0
+	if gasLimit > 0 {
+		capacity = gasLimit / unit
+	}
+	if len(chains) > 0 && capacity >= exactPackingCells/len(chains) {
+		return nil, false
+	}
+
+	// best[c] is the highest total fee, scaled to an integer by the common denominator, with at most c units of gas
+	best := make([]*big.Int, capacity+1)
+	for c := range best {
+		best[c] = new(big.Int)
+	}
+	// choices[i][c] is the length of the prefix of the chain i chosen for c units of gas
+	choices := make([][]int32, len(chains))
+	units := make([][]int, len(chains))
+	for i, chain := range chains {
+		values := make([]*big.Int, len(chain)+1)
+		values[0] = new(big.Int)
+		units[i] = make([]int, len(chain)+1)
+		for k, e := range chain {
+			value := new(big.Int).Quo(denom, e.priority.Denom())
+			value.Mul(value, e.priority.Num())
+			values[k+1] = value.Add(value, values[k])
+			units[i][k+1] = units[i][k] + e.tx.Gas/unit
+		}
+
+		choices[i] = make([]int32, capacity+1)
+		for c := capacity; c >= 0; c-- {
+			// The lower capacities are not updated yet, so they hold the totals without the chain
+			previous := best[c]
+			for k := 1; k <= len(chain) && units[i][k] <= c; k++ {
+				without := best[c-units[i][k]]
+				if units[i][k] == 0 {
+					without = previous
+				}
+				if total := new(big.Int).Add(without, values[k]); total.Cmp(best[c]) > 0 {
+					best[c] = total
+					choices[i][c] = int32(k)
+				}
+			}
+		}
+	}
+
+	selected := make(map[string]bool)
+	c := capacity
+	for i := len(chains) - 1; i >= 0; i-- {
+		k := int(choices[i][c])
+		for _, e := range chains[i][:k] {
+			selected[entryKey(e)] = true
+		}
+		c -= units[i][k]
+	}
+	return selected, true
+}
+
+// packApproximate selects the transactions by their fee per gas, skipping the ones which don't fit, and takes the
+// single transaction with the highest fee instead if it is higher than the total fee of the selected ones. For the
+// transactions of different senders the result is at least half of the optimal one. Then the result is improved by
+// adding the transactions which fit and by swapping the last selected transaction of a chain for a better one
+func packApproximate(chains []packingChain, gasLimit int) map[string]bool {
+	// taken[i] is the length of the selected prefix of the chain i
+	taken := make([]int, len(chains))
+	gas := 0
+	total := new(big.Rat)
+	dense := NewPriorityQueueFunc(math.MaxInt, densityLess)
+	heads := make([]packingItem, 0, len(chains))
+	for i := range chains {
+		heads = append(heads, newPackingItem(chains, i, 0))
+	}
+	dense.PushBatch(heads)
+	for dense.Len() > 0 {
+		item := dense.Pop()
+		// The transactions without gas come first, so once the gas limit is reached nothing else fits
+		if gas >= gasLimit && item.density != nil {
+			break
+		}
+		if item.entry.tx.Gas > gasLimit-gas || item.entry.priority.Sign() < 0 {
+			continue
+		}
+		taken[item.chain]++
+		gas += item.entry.tx.Gas
+		total.Add(total, item.entry.priority)
+		if next := item.index + 1; next < len(chains[item.chain]) {
+			dense.Push(newPackingItem(chains, item.chain, next))
+		}
+	}
+
+	single := -1
+	for i, chain := range chains {
+		if chain[0].tx.Gas <= gasLimit && chain[0].priority.Cmp(total) > 0 &&
+			(single < 0 || chain[0].priority.Cmp(chains[single][0].priority) > 0) {
+			single = i
+		}
+	}
+	if single >= 0 {
+		taken = make([]int, len(chains))
+		taken[single] = 1
+		gas = chains[single][0].tx.Gas
+	}
+
+	remaining := gasLimit - gas
+	for round := 0; round < maxImprovementRounds; round++ {
+		var improved bool
+		if remaining, improved = improvePacking(chains, taken, remaining); !improved {
+			break
+		}
+	}
+
+	selected := make(map[string]bool)
+	for i, chain := range chains {
+		for _, e := range chain[:taken[i]] {
+			selected[entryKey(e)] = true
+		}
+	}
+	return selected
+}
+
+// improvePacking makes the move with the highest gain: either adding the next transaction of a chain which fits
+// the remaining gas, or swapping the last selected transaction of a chain for the next one of another chain.
+// It returns the remaining gas after the move and false if no move increases the total fee
+func improvePacking(chains []packingChain, taken []int, remaining int) (int, bool) {
+	bestGain := new(big.Rat)
+	bestOut, bestIn := -1, -1
+	// The chain -1 stands for adding a transaction without removing any
+	for out := -1; out < len(chains); out++ {
+		budget := remaining
+		removed := new(big.Rat)
+		if out >= 0 {
+			if taken[out] == 0 {
+				continue
+			}
+			last := chains[out][taken[out]-1]
+			budget += last.tx.Gas
+			removed = last.priority
+		}
+
+		in := -1
+		for i, chain := range chains {
+			if i == out || taken[i] == len(chain) {
+				continue
+			}
+			next := chain[taken[i]]
+			if next.tx.Gas <= budget && (in < 0 || next.priority.Cmp(chains[in][taken[in]].priority) > 0) {
+				in = i
+			}
+		}
+		if in < 0 {
+			continue
+		}
+		if gain := new(big.Rat).Sub(chains[in][taken[in]].priority, removed); gain.Cmp(bestGain) > 0 {
+			bestGain, bestOut, bestIn = gain, out, in
+		}
+	}
+	if bestIn < 0 {
+		return remaining, false
+	}
+
+	if bestOut >= 0 {
+		taken[bestOut]--
+		remaining += chains[bestOut][taken[bestOut]].tx.Gas
+	}
+	remaining -= chains[bestIn][taken[bestIn]].tx.Gas
+	taken[bestIn]++
+	return remaining, true
+}
+
+// densityLess reports whether the item a has a lower fee per gas than b
+// The transactions without gas have the highest fee per gas
+func densityLess(a, b packingItem) bool {
+	if a.density == nil || b.density == nil {
+		if (a.density == nil) != (b.density == nil) {
+			return a.density != nil
+		}
+		return a.entry.priority.Cmp(b.entry.priority) < 0
+	}
+	return a.density.Cmp(b.density) < 0
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+func lcm(a, b *big.Int) *big.Int {
+	g := new(big.Int).GCD(nil, nil, a, b)
+	return new(big.Int).Mul(a, new(big.Int).Quo(b, g))
+}
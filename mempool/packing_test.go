@@ -0,0 +1,202 @@
+package mempool
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemPool_BuildBlockOptimal(t *testing.T) {
+	tests := map[string]struct {
+		options        []Option
+		txs            []Transaction
+		gasLimit       int
+		expectedHashes []string
+		expectedFees   *big.Rat
+		// expectedApproximate are the transactions selected by the approximate packing if they differ
+		expectedApproximate []string
+	}{
+		"large transaction crowds out smaller ones": {
+			txs: []Transaction{
+				{Hash: "large", Gas: 10, FeePerGas: "10", Signature: "CD"},
+				{Hash: "small1", Gas: 5, FeePerGas: "15", Signature: "CD"},
+				{Hash: "small2", Gas: 5, FeePerGas: "14", Signature: "CD"},
+			},
+			gasLimit:       10,
+			expectedHashes: []string{"small1", "small2"},
+			expectedFees:   big.NewRat(145, 1),
+		},
+		"dense transaction doesn't fill the block": {
+			txs: []Transaction{
+				{Hash: "dense", Gas: 1, FeePerGas: "20", Signature: "CD"},
+				{Hash: "full", Gas: 10, FeePerGas: "3", Signature: "CD"},
+			},
+			gasLimit:       10,
+			expectedHashes: []string{"full"},
+			expectedFees:   big.NewRat(30, 1),
+		},
+		"nonce ordering": {
+			options: []Option{WithNonceOrdering()},
+			txs: []Transaction{
+				nonceTransaction(t, "alice", 0, 1),
+				nonceTransaction(t, "alice", 1, 10),
+				{Hash: "bob", Gas: 2, FeePerGas: "5", Signature: "CD"},
+			},
+			gasLimit:       2,
+			expectedHashes: []string{"alice0-1", "alice1-10"},
+			expectedFees:   big.NewRat(11, 1),
+			// The fee per gas of the first transaction of alice is too low
+			expectedApproximate: []string{"bob"},
+		},
+		"nothing fits": {
+			txs: []Transaction{
+				{Hash: "large", Gas: 10, FeePerGas: "10", Signature: "CD"},
+			},
+			gasLimit:     9,
+			expectedFees: new(big.Rat),
+		},
+	}
+
+	for tName, tc := range tests {
+		tc := tc
+		t.Run(tName, func(t *testing.T) {
+			memPool := NewMemPool(tc.options...)
+			for _, tx := range tc.txs {
+				mustPush(t, memPool, tx)
+			}
+
+			block := memPool.BuildBlockWith(tc.gasLimit, PackOptimal)
+			require.Equal(t, tc.expectedHashes, hashes(block.Transactions))
+			require.Zero(t, tc.expectedFees.Cmp(block.Fees), "expected %s, got %s", tc.expectedFees.RatString(), block.Fees.RatString())
+			require.Len(t, block.Leftover, len(tc.txs)-len(tc.expectedHashes))
+
+			expectedApproximate := tc.expectedApproximate
+			if expectedApproximate == nil {
+				expectedApproximate = tc.expectedHashes
+			}
+			require.Equal(t, packSelection(expectedApproximate), packApproximate(memPool.packingChains(), tc.gasLimit))
+		})
+	}
+}
+
+func packSelection(hashes []string) map[string]bool {
+	selected := make(map[string]bool)
+	for _, hash := range hashes {
+		selected[normalizeHash(hash)] = true
+	}
+	return selected
+}
+
+// bestPacking returns the highest total fee of the transactions which fit the gas limit by trying all the subsets
+func bestPacking(txs []Transaction, gasLimit int) *big.Rat {
+	best := new(big.Rat)
+	for subset := 0; subset < 1<<len(txs); subset++ {
+		gas, fees := 0, new(big.Rat)
+		for i, tx := range txs {
+			if subset&(1<<i) != 0 {
+				gas += tx.Gas
+				fees.Add(fees, tx.exactFee())
+			}
+		}
+		if gas <= gasLimit && fees.Cmp(best) > 0 {
+			best = fees
+		}
+	}
+	return best
+}
+
+func TestMemPool_BuildBlockOptimalRandom(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for round := 0; round < 200; round++ {
+		txs := make([]Transaction, 1+random.Intn(10))
+		for i := range txs {
+			fee := fmt.Sprintf("%d.%d", random.Intn(50), random.Intn(10))
+			txs[i] = Transaction{Hash: fmt.Sprintf("%d", i), Gas: 1000 * (1 + random.Intn(20)), FeePerGas: fee, Signature: "CD"}
+		}
+		gasLimit := random.Intn(50000)
+		memPool := NewMemPool()
+		require.NoError(t, memPool.PushBatch(txs))
+
+		best := bestPacking(txs, gasLimit)
+		block := memPool.BuildBlockWith(gasLimit, PackOptimal)
+		require.Zero(t, best.Cmp(block.Fees), "expected %s, got %s", best.RatString(), block.Fees.RatString())
+		require.LessOrEqual(t, block.Gas, gasLimit)
+
+		// The approximate packing yields at least half of the optimal fees
+		gas, fees := 0, new(big.Rat)
+		for key := range packApproximate(memPool.packingChains(), gasLimit) {
+			e, _ := memPool.getEntry(key)
+			gas += e.tx.Gas
+			fees.Add(fees, e.priority)
+		}
+		require.LessOrEqual(t, gas, gasLimit)
+		require.LessOrEqual(t, fees.Cmp(best), 0)
+		require.GreaterOrEqual(t, new(big.Rat).Mul(fees, big.NewRat(2, 1)).Cmp(best), 0)
+	}
+}
+
+func readTransactionsFile(t testing.TB) *MemPool {
+	input, err := os.ReadFile("../transactions.txt")
+	require.NoError(t, err)
+	memPool := NewMemPool(WithTieBreak(TieBreakArrival))
+	require.NoError(t, memPool.ReadTransactions(bytes.NewReader(input)))
+	return memPool
+}
+
+func TestMemPool_BuildBlockOptimalTransactionsFile(t *testing.T) {
+	memPool := readTransactionsFile(t)
+	for _, gasLimit := range []int{1000000, 3000000, 30000000} {
+		greedy := memPool.BuildBlock(gasLimit)
+		optimal := memPool.BuildBlockWith(gasLimit, PackOptimal)
+		require.LessOrEqual(t, optimal.Gas, gasLimit)
+		require.GreaterOrEqual(t, optimal.Fees.Cmp(greedy.Fees), 0)
+		require.Equal(t, memPool.Len(), len(optimal.Transactions)+len(optimal.Leftover))
+	}
+}
+
+// BenchmarkMemPool_BuildBlock compares the fees of the blocks built from transactions.txt by the strategies
+func BenchmarkMemPool_BuildBlock(b *testing.B) {
+	memPool := readTransactionsFile(b)
+	strategies := []struct {
+		name     string
+		strategy PackingStrategy
+	}{
+		{name: "greedy", strategy: PackGreedy},
+		{name: "optimal", strategy: PackOptimal},
+	}
+	for _, gasLimit := range []int{3000000, 30000000} {
+		for _, s := range strategies {
+			s := s
+			b.Run(fmt.Sprintf("%s/%d", s.name, gasLimit), func(b *testing.B) {
+				var block Block
+				for i := 0; i < b.N; i++ {
+					block = memPool.BuildBlockWith(gasLimit, s.strategy)
+				}
+				fees, _ := block.Fees.Float64()
+				b.ReportMetric(fees, "fees")
+				b.ReportMetric(float64(block.Gas), "gas")
+			})
+		}
+	}
+}
+
+// BenchmarkMemPool_PackGreedy measures the greedy selection of BuildBlock alone, which stops once the block is full,
+// without collecting the leftover transactions
+func BenchmarkMemPool_PackGreedy(b *testing.B) {
+	memPool := readTransactionsFile(b)
+	for _, gasLimit := range []int{3000000, 30000000} {
+		gasLimit := gasLimit
+		b.Run(fmt.Sprintf("%d", gasLimit), func(b *testing.B) {
+			var entries []poolEntry
+			for i := 0; i < b.N; i++ {
+				entries = memPool.packGreedy(gasLimit)
+			}
+			b.ReportMetric(float64(len(entries)), "txs")
+		})
+	}
+}
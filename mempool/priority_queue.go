@@ -5,72 +5,104 @@ import "container/heap"
 // This is an implementation of priority queue with capacity which uses max heap and min heap with the same items referencing each other
 // This allows to remove items from the head and the tail of the queue with O(log(n)) complexity, allowing not to discard
 // the lowest priority item when the capacity is reached and keeping the O(log(n)) complexity of insert operation
-type queueItem struct {
-	pairItem     *queueItem
-	idx          int
-	priorityItem PriorityItem
+type queueItem[T any] struct {
+	pairItem *queueItem[T]
+	idx      int
+	item     T
 }
 
+// PriorityItem is the item type of the queue created by NewPriorityQueue
 type PriorityItem interface {
 	Priority() float64
 }
 
-type queue []*queueItem
+type queue[T any] []*queueItem[T]
 
-type maxQueue struct {
-	queue
+type maxQueue[T any] struct {
+	queue[T]
+	less func(a, b T) bool
 }
 
-func (q maxQueue) Less(i, j int) bool {
-	return q.queue[i].priorityItem.Priority() > q.queue[j].priorityItem.Priority()
+func (q maxQueue[T]) Less(i, j int) bool {
+	return q.less(q.queue[j].item, q.queue[i].item)
 }
 
-type minQueue struct {
-	queue
+type minQueue[T any] struct {
+	queue[T]
+	less func(a, b T) bool
 }
 
-func (q minQueue) Less(i, j int) bool {
-	return q.queue[i].priorityItem.Priority() < q.queue[j].priorityItem.Priority()
+func (q minQueue[T]) Less(i, j int) bool {
+	return q.less(q.queue[i].item, q.queue[j].item)
 }
 
-func (q queue) Len() int {
+func (q queue[T]) Len() int {
 	return len(q)
 }
 
-func (q queue) Swap(i, j int) {
+func (q queue[T]) Swap(i, j int) {
 	q[i], q[j] = q[j], q[i]
 	q[i].idx = i
 	q[j].idx = j
 }
 
-type PriorityQueue struct {
-	minQueue *minQueue
-	maxQueue *maxQueue
+// PriorityQueueOf is a bounded priority queue of items of type T ordered by a caller-supplied comparator
+type PriorityQueueOf[T any] struct {
+	minQueue *minQueue[T]
+	maxQueue *maxQueue[T]
 	capacity int
 }
 
-func (q *queue) Push(x interface{}) {
+func (q *queue[T]) Push(x interface{}) {
 	n := len(*q)
-	item := x.(*queueItem)
+	item := x.(*queueItem[T])
 	item.idx = n
 	*q = append(*q, item)
 }
 
-func (q *queue) Pop() interface{} {
+func (q *queue[T]) Pop() interface{} {
 	old := *q
 	n := len(old)
 	item := old[n-1]
-	old[n-1] = nil  // avoid memory leak
-	item.idx = -1 // for safety
+	old[n-1] = nil // avoid memory leak
+	item.idx = -1  // for safety
 	*q = old[0 : n-1]
 	return item
 }
 
+// PushResult describes the outcome of adding an item to a priority queue
+type PushResult[T any] struct {
+	// Admitted is false when the queue is full and the item has the lowest priority, so it is dropped right away
+	Admitted bool
+	// Evicted is the item with the lowest priority dropped to make room for the added item
+	Evicted *T
+	// Replaced is the item with the same key replaced by the added item, only set by KeyedPriorityQueue
+	Replaced *T
+}
+
 // Push adds an item to the priority queue
 // When the capacity is surpassed, item with the lowest priority will be dropped
-func (m *PriorityQueue) Push(item PriorityItem) {
-	minItem := queueItem{priorityItem: item}
-	maxItem := queueItem{priorityItem: item}
+func (m *PriorityQueueOf[T]) Push(item T) PushResult[T] {
+	added, dropped := m.push(item)
+	return newPushResult(added, dropped)
+}
+
+func newPushResult[T any](added, dropped *queueItem[T]) PushResult[T] {
+	if dropped == nil {
+		return PushResult[T]{Admitted: true}
+	}
+	if dropped == added {
+		return PushResult[T]{Admitted: false}
+	}
+	evicted := dropped.item
+	return PushResult[T]{Admitted: true, Evicted: &evicted}
+}
+
+// push returns the max heap items of the added item and of the item dropped due to the capacity, if any
+// The dropped item can be the added one
+func (m *PriorityQueueOf[T]) push(item T) (added, dropped *queueItem[T]) {
+	minItem := queueItem[T]{item: item}
+	maxItem := queueItem[T]{item: item}
 	maxItem.pairItem = &minItem
 	minItem.pairItem = &maxItem
 
@@ -79,31 +111,122 @@ func (m *PriorityQueue) Push(item PriorityItem) {
 
 	if m.minQueue.Len() > m.capacity {
 		item := heap.Pop(m.minQueue)
-		tx := item.(*queueItem)
+		tx := item.(*queueItem[T])
 		heap.Remove(m.maxQueue, tx.pairItem.idx)
+		dropped = tx.pairItem
 	}
+
+	return &maxItem, dropped
+}
+
+// PushBatch adds the items to the priority queue, building the heaps in O(n) rather than pushing the items one by one
+// When the capacity is surpassed, the items with the lowest priority are dropped, which can be the pushed items as well.
+// The dropped items are returned from the lowest priority
+func (m *PriorityQueueOf[T]) PushBatch(items []T) []T {
+	_, dropped := m.pushBatch(items)
+	droppedItems := make([]T, 0, len(dropped))
+	for _, qItem := range dropped {
+		droppedItems = append(droppedItems, qItem.item)
+	}
+	return droppedItems
+}
+
+// pushBatch returns the max heap items of the added items and of the items dropped due to the capacity
+// The dropped items can be among the added ones
+func (m *PriorityQueueOf[T]) pushBatch(items []T) (added, dropped []*queueItem[T]) {
+	added = make([]*queueItem[T], 0, len(items))
+	for _, item := range items {
+		minItem := &queueItem[T]{item: item, idx: m.minQueue.Len()}
+		maxItem := &queueItem[T]{item: item, idx: m.maxQueue.Len(), pairItem: minItem}
+		minItem.pairItem = maxItem
+		m.minQueue.queue = append(m.minQueue.queue, minItem)
+		m.maxQueue.queue = append(m.maxQueue.queue, maxItem)
+		added = append(added, maxItem)
+	}
+	heap.Init(m.minQueue)
+	heap.Init(m.maxQueue)
+
+	for m.minQueue.Len() > m.capacity {
+		tx := heap.Pop(m.minQueue).(*queueItem[T])
+		heap.Remove(m.maxQueue, tx.pairItem.idx)
+		dropped = append(dropped, tx.pairItem)
+	}
+
+	return added, dropped
+}
+
+// remove removes the item by its max heap item
+func (m *PriorityQueueOf[T]) remove(maxItem *queueItem[T]) {
+	heap.Remove(m.maxQueue, maxItem.idx)
+	heap.Remove(m.minQueue, maxItem.pairItem.idx)
+}
+
+// update replaces the item referenced by its max heap item and restores the order of both heaps
+func (m *PriorityQueueOf[T]) update(maxItem *queueItem[T], item T) {
+	maxItem.item = item
+	maxItem.pairItem.item = item
+	heap.Fix(m.maxQueue, maxItem.idx)
+	heap.Fix(m.minQueue, maxItem.pairItem.idx)
 }
 
 // Pop retrieves and removes the item with the highest priority
 // If the queue is empty, the function will panic
-func (m *PriorityQueue) Pop() PriorityItem {
+func (m *PriorityQueueOf[T]) Pop() T {
 	item := heap.Pop(m.maxQueue)
-	if item == nil {
-		return nil
-	}
-	tx := item.(*queueItem)
+	tx := item.(*queueItem[T])
 	heap.Remove(m.minQueue, tx.pairItem.idx)
-	return tx.priorityItem
+	return tx.item
 }
 
-func (m PriorityQueue) Len() int {
+// Peek returns the item with the highest priority without removing it
+// The second return value is false if the queue is empty
+func (m PriorityQueueOf[T]) Peek() (T, bool) {
+	if m.maxQueue.Len() == 0 {
+		var zero T
+		return zero, false
+	}
+	return m.maxQueue.queue[0].item, true
+}
+
+// PeekLowest returns the item with the lowest priority without removing it
+// The second return value is false if the queue is empty
+func (m PriorityQueueOf[T]) PeekLowest() (T, bool) {
+	if m.minQueue.Len() == 0 {
+		var zero T
+		return zero, false
+	}
+	return m.minQueue.queue[0].item, true
+}
+
+// Descend calls fn for the items from the highest to the lowest priority until fn returns false
+// The queue must not be modified by fn
+func (m PriorityQueueOf[T]) Descend(fn func(item T) bool) {
+	walkHeap(m.maxQueue.queue, m.maxQueue.Less, fn)
+}
+
+// Ascend calls fn for the items from the lowest to the highest priority until fn returns false
+// The queue must not be modified by fn
+func (m PriorityQueueOf[T]) Ascend(fn func(item T) bool) {
+	walkHeap(m.minQueue.queue, m.minQueue.Less, fn)
+}
+
+// each calls fn for the items in no particular order, which is faster than walking them in order
+func (m PriorityQueueOf[T]) each(fn func(item T)) {
+	for _, item := range m.maxQueue.queue {
+		fn(item.item)
+	}
+}
+
+func (m PriorityQueueOf[T]) Len() int {
 	return m.maxQueue.Len()
 }
 
-func NewPriorityQueue(capacity int) PriorityQueue {
-	m := PriorityQueue{
-		minQueue: &minQueue{queue: queue{}},
-		maxQueue: &maxQueue{queue: queue{}},
+// NewPriorityQueueFunc creates a priority queue with the given capacity
+// less reports whether a has a lower priority than b
+func NewPriorityQueueFunc[T any](capacity int, less func(a, b T) bool) PriorityQueueOf[T] {
+	m := PriorityQueueOf[T]{
+		minQueue: &minQueue[T]{queue: queue[T]{}, less: less},
+		maxQueue: &maxQueue[T]{queue: queue[T]{}, less: less},
 		capacity: capacity,
 	}
 
@@ -111,3 +234,63 @@ func NewPriorityQueue(capacity int) PriorityQueue {
 	heap.Init(m.maxQueue)
 	return m
 }
+
+// PriorityQueue is the queue of PriorityItem values ordered by their Priority, as it was before the queue was generic
+type PriorityQueue = PriorityQueueOf[PriorityItem]
+
+// NewPriorityQueue creates a priority queue of PriorityItem values ordered by their Priority
+func NewPriorityQueue(capacity int) PriorityQueue {
+	return NewPriorityQueueFunc(capacity, func(a, b PriorityItem) bool {
+		return a.Priority() < b.Priority()
+	})
+}
+
+// walkHeap visits the items of the heap in order without modifying it
+// The next item in order is always a child of one of the already visited items, so only these children
+// are kept in a separate heap of indices, which makes visiting k items O(k*log(k))
+func walkHeap[T any](items queue[T], less func(i, j int) bool, fn func(item T) bool) {
+	if len(items) == 0 {
+		return
+	}
+
+	frontier := &indexHeap{indices: []int{0}, less: less}
+	for frontier.Len() > 0 {
+		i := heap.Pop(frontier).(int)
+		if !fn(items[i].item) {
+			return
+		}
+		for _, child := range []int{2*i + 1, 2*i + 2} {
+			if child < len(items) {
+				heap.Push(frontier, child)
+			}
+		}
+	}
+}
+
+type indexHeap struct {
+	indices []int
+	less    func(i, j int) bool
+}
+
+func (h indexHeap) Len() int {
+	return len(h.indices)
+}
+
+func (h indexHeap) Less(i, j int) bool {
+	return h.less(h.indices[i], h.indices[j])
+}
+
+func (h indexHeap) Swap(i, j int) {
+	h.indices[i], h.indices[j] = h.indices[j], h.indices[i]
+}
+
+func (h *indexHeap) Push(x interface{}) {
+	h.indices = append(h.indices, x.(int))
+}
+
+func (h *indexHeap) Pop() interface{} {
+	n := len(h.indices)
+	i := h.indices[n-1]
+	h.indices = h.indices[:n-1]
+	return i
+}
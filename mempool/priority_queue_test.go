@@ -1,6 +1,8 @@
 package mempool
 
 import (
+	"math/rand"
+	"sort"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -10,46 +12,46 @@ type testPriorityItem struct {
 	priority float64
 }
 
-func (t testPriorityItem) Priority() float64  {
+func (t testPriorityItem) Priority() float64 {
 	return t.priority
 }
 
 func TestPriorityQueue_PriorityOrdering(t *testing.T) {
 	tests := map[string]struct {
-		inputTxs []testPriorityItem
+		inputTxs       []testPriorityItem
 		expectedOutput []testPriorityItem
-	} {
+	}{
 		"permutation 1": {
-			inputTxs: []testPriorityItem{{priority: 1},{priority: 2},{priority: 3}},
-			expectedOutput: []testPriorityItem{{priority: 3},{priority: 2},{priority: 1}},
+			inputTxs:       []testPriorityItem{{priority: 1}, {priority: 2}, {priority: 3}},
+			expectedOutput: []testPriorityItem{{priority: 3}, {priority: 2}, {priority: 1}},
 		},
 		"permutation 2": {
-			inputTxs: []testPriorityItem{{priority: 1},{priority: 3},{priority: 2}},
-			expectedOutput: []testPriorityItem{{priority: 3},{priority: 2},{priority: 1}},
+			inputTxs:       []testPriorityItem{{priority: 1}, {priority: 3}, {priority: 2}},
+			expectedOutput: []testPriorityItem{{priority: 3}, {priority: 2}, {priority: 1}},
 		},
 		"permutation 3": {
-			inputTxs: []testPriorityItem{{priority: 2},{priority: 1},{priority: 3}},
-			expectedOutput: []testPriorityItem{{priority: 3},{priority: 2},{priority: 1}},
+			inputTxs:       []testPriorityItem{{priority: 2}, {priority: 1}, {priority: 3}},
+			expectedOutput: []testPriorityItem{{priority: 3}, {priority: 2}, {priority: 1}},
 		},
 		"permutation 4": {
-			inputTxs: []testPriorityItem{{priority: 2},{priority: 3},{priority: 1}},
-			expectedOutput: []testPriorityItem{{priority: 3},{priority: 2},{priority: 1}},
+			inputTxs:       []testPriorityItem{{priority: 2}, {priority: 3}, {priority: 1}},
+			expectedOutput: []testPriorityItem{{priority: 3}, {priority: 2}, {priority: 1}},
 		},
 		"permutation 5": {
-			inputTxs: []testPriorityItem{{priority: 3},{priority: 1},{priority: 2}},
-			expectedOutput: []testPriorityItem{{priority: 3},{priority: 2},{priority: 1}},
+			inputTxs:       []testPriorityItem{{priority: 3}, {priority: 1}, {priority: 2}},
+			expectedOutput: []testPriorityItem{{priority: 3}, {priority: 2}, {priority: 1}},
 		},
 		"permutation 6": {
-			inputTxs: []testPriorityItem{{priority: 3},{priority: 2},{priority: 1}},
-			expectedOutput: []testPriorityItem{{priority: 3},{priority: 2},{priority: 1}},
+			inputTxs:       []testPriorityItem{{priority: 3}, {priority: 2}, {priority: 1}},
+			expectedOutput: []testPriorityItem{{priority: 3}, {priority: 2}, {priority: 1}},
 		},
 		"empty": {
-			inputTxs: []testPriorityItem{},
+			inputTxs:       []testPriorityItem{},
 			expectedOutput: []testPriorityItem{},
 		},
 		"equal items": {
-			inputTxs: []testPriorityItem{{priority: 1},{priority: 1},{priority: 3}},
-			expectedOutput: []testPriorityItem{{priority: 3},{priority: 1},{priority: 1}},
+			inputTxs:       []testPriorityItem{{priority: 1}, {priority: 1}, {priority: 3}},
+			expectedOutput: []testPriorityItem{{priority: 3}, {priority: 1}, {priority: 1}},
 		},
 	}
 
@@ -73,10 +75,9 @@ func TestPriorityQueue_PriorityOrdering(t *testing.T) {
 	}
 }
 
-
 func TestPriorityQueue_Capacity(t *testing.T) {
 	priorityQueue := NewPriorityQueue(10)
-	for i := 0; i <  15; i++ {
+	for i := 0; i < 15; i++ {
 		priorityQueue.Push(testPriorityItem{priority: float64(i)})
 	}
 
@@ -115,4 +116,186 @@ func TestPriorityQueue_PoppingFromEmpty(t *testing.T) {
 	require.Panics(t, func() {
 		priorityQueue.Pop()
 	})
-}
\ No newline at end of file
+}
+
+// testQueueHolder names the queue type as the callers did before the queue was generic
+type testQueueHolder struct {
+	queue PriorityQueue
+}
+
+func pushPriorities(q *PriorityQueue, priorities ...float64) {
+	for _, priority := range priorities {
+		q.Push(testPriorityItem{priority: priority})
+	}
+}
+
+func TestPriorityQueue_NonGenericName(t *testing.T) {
+	holder := testQueueHolder{queue: NewPriorityQueue(2)}
+	pushPriorities(&holder.queue, 1, 3, 2)
+	require.Equal(t, 2, holder.queue.Len())
+	require.Equal(t, float64(3), holder.queue.Pop().Priority())
+	require.Equal(t, float64(2), holder.queue.Pop().Priority())
+}
+
+type testNamedItem struct {
+	name string
+	fee  int
+}
+
+func TestPriorityQueueFunc_ConcreteType(t *testing.T) {
+	priorityQueue := NewPriorityQueueFunc(3, func(a, b testNamedItem) bool {
+		return a.fee < b.fee
+	})
+	priorityQueue.Push(testNamedItem{name: "b", fee: 20})
+	priorityQueue.Push(testNamedItem{name: "d", fee: 5})
+	priorityQueue.Push(testNamedItem{name: "a", fee: 30})
+	priorityQueue.Push(testNamedItem{name: "c", fee: 10})
+
+	require.Equal(t, 3, priorityQueue.Len())
+
+	var output []testNamedItem
+	for priorityQueue.Len() > 0 {
+		output = append(output, priorityQueue.Pop())
+	}
+
+	require.Equal(t, []testNamedItem{{name: "a", fee: 30}, {name: "b", fee: 20}, {name: "c", fee: 10}}, output)
+}
+
+func TestPriorityQueueFunc_ReversedComparator(t *testing.T) {
+	priorityQueue := NewPriorityQueueFunc(2, func(a, b int) bool {
+		return a > b
+	})
+	for _, i := range []int{3, 1, 4, 2} {
+		priorityQueue.Push(i)
+	}
+
+	require.Equal(t, 1, priorityQueue.Pop())
+	require.Equal(t, 2, priorityQueue.Pop())
+	require.Equal(t, 0, priorityQueue.Len())
+}
+
+func TestPriorityQueue_Peek(t *testing.T) {
+	priorityQueue := NewPriorityQueue(100)
+	_, ok := priorityQueue.Peek()
+	require.False(t, ok)
+	_, ok = priorityQueue.PeekLowest()
+	require.False(t, ok)
+
+	for _, p := range []float64{2, 5, 1, 3} {
+		priorityQueue.Push(testPriorityItem{priority: p})
+	}
+
+	item, ok := priorityQueue.Peek()
+	require.True(t, ok)
+	require.Equal(t, testPriorityItem{priority: 5}, item)
+	item, ok = priorityQueue.PeekLowest()
+	require.True(t, ok)
+	require.Equal(t, testPriorityItem{priority: 1}, item)
+	require.Equal(t, 4, priorityQueue.Len())
+}
+
+func TestPriorityQueue_AscendAndDescend(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	priorityQueue := NewPriorityQueueFunc(1000, func(a, b int) bool {
+		return a < b
+	})
+	var expected []int
+	for i := 0; i < 500; i++ {
+		n := rnd.Intn(200)
+		expected = append(expected, n)
+		priorityQueue.Push(n)
+	}
+	sort.Ints(expected)
+
+	var ascending []int
+	priorityQueue.Ascend(func(item int) bool {
+		ascending = append(ascending, item)
+		return true
+	})
+	require.Equal(t, expected, ascending)
+
+	var descending []int
+	priorityQueue.Descend(func(item int) bool {
+		descending = append(descending, item)
+		return true
+	})
+	sort.Sort(sort.Reverse(sort.IntSlice(expected)))
+	require.Equal(t, expected, descending)
+
+	// iterating must not modify the queue
+	require.Equal(t, 500, priorityQueue.Len())
+	for _, n := range expected {
+		require.Equal(t, n, priorityQueue.Pop())
+	}
+}
+
+func TestPriorityQueue_DescendStops(t *testing.T) {
+	priorityQueue := NewPriorityQueue(100)
+	for i := 0; i < 10; i++ {
+		priorityQueue.Push(testPriorityItem{priority: float64(i)})
+	}
+
+	var output []PriorityItem
+	priorityQueue.Descend(func(item PriorityItem) bool {
+		output = append(output, item)
+		return len(output) < 3
+	})
+
+	require.Equal(t, []PriorityItem{testPriorityItem{priority: 9}, testPriorityItem{priority: 8}, testPriorityItem{priority: 7}}, output)
+}
+
+func TestPriorityQueue_PushResult(t *testing.T) {
+	priorityQueue := NewPriorityQueueFunc(2, func(a, b int) bool {
+		return a < b
+	})
+
+	require.Equal(t, PushResult[int]{Admitted: true}, priorityQueue.Push(2))
+	require.Equal(t, PushResult[int]{Admitted: true}, priorityQueue.Push(3))
+	require.Equal(t, PushResult[int]{Admitted: false}, priorityQueue.Push(1))
+
+	evicted := 2
+	require.Equal(t, PushResult[int]{Admitted: true, Evicted: &evicted}, priorityQueue.Push(4))
+	require.Equal(t, 2, priorityQueue.Len())
+}
+
+func TestPriorityQueue_PushBatch(t *testing.T) {
+	priorityQueue := NewPriorityQueueFunc(5, func(a, b int) bool {
+		return a < b
+	})
+	priorityQueue.Push(4)
+	priorityQueue.Push(10)
+
+	dropped := priorityQueue.PushBatch([]int{7, 1, 9, 3, 8, 2})
+	require.Equal(t, []int{1, 2, 3}, dropped)
+
+	lowest, ok := priorityQueue.PeekLowest()
+	require.True(t, ok)
+	require.Equal(t, 4, lowest)
+
+	var output []int
+	for priorityQueue.Len() > 0 {
+		output = append(output, priorityQueue.Pop())
+	}
+	require.Equal(t, []int{10, 9, 8, 7, 4}, output)
+}
+
+func TestPriorityQueue_PushBatchMatchesPush(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	less := func(a, b int) bool {
+		return a < b
+	}
+	batchQueue := NewPriorityQueueFunc(100, less)
+	pushQueue := NewPriorityQueueFunc(100, less)
+
+	items := make([]int, 500)
+	for i := range items {
+		items[i] = rnd.Intn(1000)
+		pushQueue.Push(items[i])
+	}
+	require.Len(t, batchQueue.PushBatch(items), 400)
+
+	for pushQueue.Len() > 0 {
+		require.Equal(t, pushQueue.Pop(), batchQueue.Pop())
+	}
+	require.Equal(t, 0, batchQueue.Len())
+}
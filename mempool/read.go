@@ -0,0 +1,36 @@
+package mempool
+
+import "errors"
+
+var ErrTooManyErrors = errors.New("Too many invalid lines")
+
+// ReadOptions configure how MemPool.ReadTransactionsWith decodes the input and handles the invalid records
+type ReadOptions struct {
+	// Codec is the format of the input, TextCodec is used if it is nil
+	Codec Codec
+	// Lenient makes the reading skip the invalid lines instead of stopping on the first one
+	Lenient bool
+	// MaxErrors stops the lenient reading with ErrTooManyErrors once more lines are rejected, 0 means no limit
+	MaxErrors int
+	// Atomic makes the pool change only after the whole input is read successfully, so a failed reading leaves it as before
+	Atomic bool
+}
+
+// ReadReport summarizes the reading of the transactions
+type ReadReport struct {
+	// Accepted is the number of the transactions passed to the pool, some of them could be evicted due to the capacity
+	Accepted int
+	// Rejected is the number of the invalid lines
+	Rejected int
+	// Quarantined is the number of the transactions with invalid signatures kept aside of the pool
+	Quarantined int
+	// Errors are the errors of the rejected lines in the order of the lines, ParseError.Text holds the line
+	Errors []*ParseError
+}
+
+func (o ReadOptions) codec() Codec {
+	if o.Codec == nil {
+		return TextCodec{}
+	}
+	return o.Codec
+}
@@ -0,0 +1,165 @@
+package mempool
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const lenientInput = `TxHash=A1 Gas=1 FeePerGas=1 Signature=CD
+TxHash=A2 Gas=x FeePerGas=1 Signature=CD
+
+TxHash=A3 Gas=1 FeePerGas=3 Signature=CD
+garbage
+TxHash=A4 Gas=1 Signature=CD
+TxHash=A5 Gas=1 FeePerGas=5 Signature=CD`
+
+func TestMemPool_ReadTransactionsLenient(t *testing.T) {
+	memPool := NewMemPool()
+	report, err := memPool.ReadTransactionsWith(strings.NewReader(lenientInput), ReadOptions{Lenient: true})
+	require.NoError(t, err)
+
+	require.Equal(t, 3, report.Accepted)
+	require.Equal(t, 3, report.Rejected)
+	require.Equal(t, 3, memPool.Len())
+
+	require.Len(t, report.Errors, 3)
+	require.Equal(t, 2, report.Errors[0].Line)
+	require.ErrorIs(t, report.Errors[0], ErrInvalidValueForField)
+	require.Equal(t, "TxHash=A2 Gas=x FeePerGas=1 Signature=CD", report.Errors[0].Text)
+	require.Equal(t, 5, report.Errors[1].Line)
+	require.ErrorIs(t, report.Errors[1], ErrInvalidToken)
+	require.Equal(t, 6, report.Errors[2].Line)
+	require.ErrorIs(t, report.Errors[2], ErrFieldNotFound)
+}
+
+func TestMemPool_ReadTransactionsLenientCSV(t *testing.T) {
+	input := "TxHash;Gas;FeePerGas;Signature;Memo\n" +
+		"A1;1;1;CD;\n" +
+		"A2;1;\"1\"x;CD;\n" +
+		"A3;1;3;CD\r\n" +
+		"\n" +
+		"A4;x;1;CD;\"two\n" +
+		"lines\"\n" +
+		"A5;1;5;CD;\"unterminated\n"
+	memPool := NewMemPool()
+	report, err := memPool.ReadTransactionsWith(strings.NewReader(input), ReadOptions{Codec: CSVCodec{Comma: ';'}, Lenient: true})
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Accepted)
+	require.Equal(t, 4, report.Rejected)
+
+	// The rejected records are kept as they are in the input, so they can be read again under the header
+	texts := make([]string, 0, len(report.Errors))
+	for _, parseErr := range report.Errors {
+		texts = append(texts, parseErr.Text)
+	}
+	require.Equal(t, []string{"A2;1;\"1\"x;CD;", "A3;1;3;CD", "A4;x;1;CD;\"two\nlines\"", "A5;1;5;CD;\"unterminated"}, texts)
+	require.Equal(t, []int{3, 4, 6, 8}, []int{report.Errors[0].Line, report.Errors[1].Line, report.Errors[2].Line, report.Errors[3].Line})
+}
+
+func TestMemPool_ReadTransactionsMaxErrors(t *testing.T) {
+	memPool := NewMemPool()
+	report, err := memPool.ReadTransactionsWith(strings.NewReader(lenientInput), ReadOptions{Lenient: true, MaxErrors: 1})
+	require.ErrorIs(t, err, ErrTooManyErrors)
+
+	require.Equal(t, 2, report.Accepted)
+	require.Equal(t, 2, report.Rejected)
+	require.Equal(t, 2, memPool.Len())
+}
+
+func TestMemPool_ReadTransactionsNotLenient(t *testing.T) {
+	memPool := NewMemPool()
+	report, err := memPool.ReadTransactionsWith(strings.NewReader(lenientInput), ReadOptions{})
+	require.ErrorIs(t, err, ErrInvalidValueForField)
+
+	require.Equal(t, ReadReport{Accepted: 1}, report)
+}
+
+func TestMemPool_ReadTransactionsLenientQuarantine(t *testing.T) {
+	registry := readTestKeyRegistry(t)
+	input := strings.Join([]string{
+		readTestTransactions(t, "testdata/signed-transactions.txt")[0].String(),
+		readTestTransactions(t, "testdata/invalid-signed-transactions.txt")[0].String(),
+		"invalid",
+	}, "\n")
+
+	memPool := NewMemPool(WithSignatureVerifier(registry, QuarantineInvalid))
+	report, err := memPool.ReadTransactionsWith(strings.NewReader(input), ReadOptions{Lenient: true})
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Accepted)
+	require.Equal(t, 1, report.Quarantined)
+	require.Equal(t, 1, report.Rejected)
+
+	memPool = NewMemPool(WithSignatureVerifier(registry, RejectInvalid))
+	report, err = memPool.ReadTransactionsWith(strings.NewReader(input), ReadOptions{Lenient: true})
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Accepted)
+	require.Equal(t, 2, report.Rejected)
+	require.ErrorIs(t, report.Errors[0], ErrInvalidSignature)
+	require.Equal(t, 2, report.Errors[0].Line)
+}
+
+func TestMemPool_ReadTransactionsAtomic(t *testing.T) {
+	memPool := NewMemPool()
+	memPool.Push(testTransaction(t, 1))
+
+	report, err := memPool.ReadTransactionsWith(strings.NewReader(lenientInput), ReadOptions{Atomic: true})
+	require.ErrorIs(t, err, ErrInvalidValueForField)
+	require.Equal(t, ReadReport{}, report)
+	require.Equal(t, 1, memPool.Len())
+
+	report, err = memPool.ReadTransactionsWith(strings.NewReader(lenientInput), ReadOptions{Atomic: true, Lenient: true, MaxErrors: 2})
+	require.ErrorIs(t, err, ErrTooManyErrors)
+	require.Equal(t, 0, report.Accepted)
+	require.Equal(t, 3, report.Rejected)
+	require.Equal(t, 1, memPool.Len())
+
+	report, err = memPool.ReadTransactionsWith(strings.NewReader(lenientInput), ReadOptions{Atomic: true, Lenient: true})
+	require.NoError(t, err)
+	require.Equal(t, 3, report.Accepted)
+	require.Equal(t, 3, report.Rejected)
+	require.Equal(t, 4, memPool.Len())
+}
+
+func TestMemPool_ReadTransactionsAtomicKeepsEvictedTransactions(t *testing.T) {
+	var evictions []Eviction
+	memPool := NewMemPool(WithCapacity(2), WithEvictionHook(func(eviction Eviction) {
+		evictions = append(evictions, eviction)
+	}))
+	memPool.Push(testTransaction(t, 1))
+	memPool.Push(testTransaction(t, 2))
+
+	input := strings.Join([]string{testTransaction(t, 3).String(), testTransaction(t, 4).String(), "invalid"}, "\n")
+	_, err := memPool.ReadTransactionsWith(strings.NewReader(input), ReadOptions{Atomic: true})
+	require.ErrorIs(t, err, ErrInvalidToken)
+	require.Empty(t, evictions)
+	require.True(t, memPool.Contains(testTransaction(t, 1).Hash))
+	require.True(t, memPool.Contains(testTransaction(t, 2).Hash))
+
+	input = strings.Join([]string{testTransaction(t, 3).String(), testTransaction(t, 4).String()}, "\n")
+	_, err = memPool.ReadTransactionsWith(strings.NewReader(input), ReadOptions{Atomic: true})
+	require.NoError(t, err)
+	require.Equal(t, []Eviction{
+		{Transaction: testTransaction(t, 1), Reason: EvictionCapacity},
+		{Transaction: testTransaction(t, 2), Reason: EvictionCapacity},
+	}, evictions)
+}
+
+func TestMemPool_ReadTransactionsAtomicQuarantine(t *testing.T) {
+	registry := readTestKeyRegistry(t)
+	input := strings.Join([]string{
+		readTestTransactions(t, "testdata/invalid-signed-transactions.txt")[0].String(),
+		"invalid",
+	}, "\n")
+
+	memPool := NewMemPool(WithSignatureVerifier(registry, QuarantineInvalid))
+	_, err := memPool.ReadTransactionsWith(strings.NewReader(input), ReadOptions{Atomic: true})
+	require.Error(t, err)
+	require.Empty(t, memPool.Quarantined())
+
+	report, err := memPool.ReadTransactionsWith(strings.NewReader(input), ReadOptions{Atomic: true, Lenient: true})
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Quarantined)
+	require.Len(t, memPool.Quarantined(), 1)
+}
@@ -0,0 +1,123 @@
+package mempool
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var ErrReplacementUnderpriced = errors.New("Replacement transaction underpriced")
+
+// replacementBatch keeps the transactions of a batch which are checked but not added to the pool yet,
+// so the later transactions of the batch are checked against the ones they would actually replace
+type replacementBatch struct {
+	txs    map[string]Transaction
+	nonces map[senderNonce]string
+	// replaced are the keys of the transactions of the pool which are replaced by the batch
+	replaced map[string]bool
+}
+
+func newReplacementBatch() *replacementBatch {
+	return &replacementBatch{
+		txs:      make(map[string]Transaction),
+		nonces:   make(map[senderNonce]string),
+		replaced: make(map[string]bool),
+	}
+}
+
+// add adds the checked transaction to the batch, the transactions it conflicts with are replaced
+func (b *replacementBatch) add(tx Transaction, conflicts []Transaction) {
+	for _, conflict := range conflicts {
+		key := normalizeHash(conflict.Hash)
+		if _, ok := b.txs[key]; ok {
+			delete(b.txs, key)
+			delete(b.nonces, senderNonce{sender: conflict.Sender, nonce: conflict.Nonce})
+		} else {
+			b.replaced[key] = true
+		}
+	}
+	key := normalizeHash(tx.Hash)
+	b.txs[key] = tx
+	if tx.Sender != "" && tx.HasNonce {
+		b.nonces[senderNonce{sender: tx.Sender, nonce: tx.Nonce}] = key
+	}
+}
+
+// conflicts returns the transactions which the transaction replaces, i.e. the one with the same hash and the one with
+// the same sender and nonce. The transactions of the batch are taken into account if it is not nil.
+// It must be called holding the lock
+func (m *MemPool) conflicts(tx Transaction, batch *replacementBatch) []Transaction {
+	if batch == nil {
+		batch = &replacementBatch{}
+	}
+
+	var conflicts []Transaction
+	key := normalizeHash(tx.Hash)
+	if batchTx, ok := batch.txs[key]; ok {
+		conflicts = append(conflicts, batchTx)
+	} else if e, ok := m.getEntry(key); ok && !batch.replaced[key] {
+		conflicts = append(conflicts, e.tx)
+	}
+	if tx.Sender == "" || !tx.HasNonce {
+		return conflicts
+	}
+
+	sn := senderNonce{sender: tx.Sender, nonce: tx.Nonce}
+	if batchKey, ok := batch.nonces[sn]; ok {
+		if batchKey != key {
+			conflicts = append(conflicts, batch.txs[batchKey])
+		}
+	} else if e, ok := m.senderNonceEntry(sn); ok {
+		if poolKey := entryKey(e); poolKey != key && !batch.replaced[poolKey] {
+			conflicts = append(conflicts, e.tx)
+		}
+	}
+	return conflicts
+}
+
+// senderNonceEntry returns the entry of the pool with the sender and the nonce
+func (m *MemPool) senderNonceEntry(sn senderNonce) (poolEntry, bool) {
+	if !m.nonceOrdering {
+		key, ok := m.senderNonces[sn]
+		if !ok {
+			return poolEntry{}, false
+		}
+		return m.getEntry(key)
+	}
+	if a, ok := m.accounts[sn.sender]; ok {
+		e, ok := a.txs[sn.nonce]
+		return e, ok
+	}
+	return poolEntry{}, false
+}
+
+// checkReplacement returns ErrReplacementUnderpriced if the pool has the replace-by-fee policy and the transaction
+// doesn't pay enough to replace the transactions it conflicts with. If the batch is not nil, the transaction is added
+// to it. It must be called holding the lock
+func (m *MemPool) checkReplacement(tx Transaction, batch *replacementBatch) error {
+	if !m.replaceByFee {
+		return nil
+	}
+
+	conflicts := m.conflicts(tx, batch)
+	for _, conflict := range conflicts {
+		if !m.outbids(tx, conflict) {
+			return fmt.Errorf("%w [%s], the fee must be at least %d%% higher than the fee of [%s]",
+				ErrReplacementUnderpriced, tx.Hash, m.replaceBump, conflict.Hash)
+		}
+	}
+	if batch != nil {
+		batch.add(tx, conflicts)
+	}
+	return nil
+}
+
+// outbids reports whether the total fee of the transaction exceeds the fee of the replaced one by the bump percentage
+func (m *MemPool) outbids(tx, replaced Transaction) bool {
+	fee, replacedFee := tx.exactFee(), replaced.exactFee()
+	if fee.Cmp(replacedFee) <= 0 {
+		return false
+	}
+	minFee := new(big.Rat).Mul(replacedFee, big.NewRat(int64(100+m.replaceBump), 100))
+	return fee.Cmp(minFee) >= 0
+}
@@ -0,0 +1,203 @@
+package mempool
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemPool_ReplaceByFee(t *testing.T) {
+	tests := map[string]struct {
+		options          []Option
+		old              Transaction
+		new              Transaction
+		expectedReplaced bool
+		expectedError    error
+		expectedLen      int
+	}{
+		"same hash with enough bump": {
+			options:          []Option{WithReplaceByFee(10)},
+			old:              Transaction{Hash: "AB", Gas: 2, FeePerGas: "50", Signature: "CD"},
+			new:              Transaction{Hash: "ab", Gas: 1, FeePerGas: "110", Signature: "CD"},
+			expectedReplaced: true,
+			expectedLen:      1,
+		},
+		"same hash with too low bump": {
+			options:       []Option{WithReplaceByFee(10)},
+			old:           Transaction{Hash: "AB", Gas: 2, FeePerGas: "50", Signature: "CD"},
+			new:           Transaction{Hash: "AB", Gas: 1, FeePerGas: "109.99", Signature: "CD"},
+			expectedError: ErrReplacementUnderpriced,
+			expectedLen:   1,
+		},
+		"zero bump requires higher fee": {
+			options:       []Option{WithReplaceByFee(0)},
+			old:           Transaction{Hash: "AB", Gas: 1, FeePerGas: "1", Signature: "CD"},
+			new:           Transaction{Hash: "AB", Gas: 1, FeePerGas: "1", Signature: "CD"},
+			expectedError: ErrReplacementUnderpriced,
+			expectedLen:   1,
+		},
+		"same sender and nonce": {
+			options:          []Option{WithReplaceByFee(10), WithNonceOrdering()},
+			old:              nonceTransaction(t, "alice", 1, 10),
+			new:              nonceTransaction(t, "alice", 1, 11),
+			expectedReplaced: true,
+			expectedLen:      1,
+		},
+		"same sender and nonce with too low bump": {
+			options:       []Option{WithReplaceByFee(10), WithNonceOrdering()},
+			old:           nonceTransaction(t, "alice", 1, 10),
+			new:           nonceTransaction(t, "alice", 1, 10),
+			expectedError: ErrReplacementUnderpriced,
+			expectedLen:   1,
+		},
+		"same sender and nonce without nonce ordering": {
+			options:          []Option{WithReplaceByFee(10)},
+			old:              nonceTransaction(t, "alice", 1, 10),
+			new:              nonceTransaction(t, "alice", 1, 11),
+			expectedReplaced: true,
+			expectedLen:      1,
+		},
+		"same sender and nonce with too low bump without nonce ordering": {
+			options:       []Option{WithReplaceByFee(10)},
+			old:           nonceTransaction(t, "alice", 1, 10),
+			new:           nonceTransaction(t, "alice", 1, 1),
+			expectedError: ErrReplacementUnderpriced,
+			expectedLen:   1,
+		},
+		"same sender and nonce without replace-by-fee": {
+			old:              nonceTransaction(t, "alice", 1, 10),
+			new:              nonceTransaction(t, "alice", 1, 1),
+			expectedReplaced: true,
+			expectedLen:      1,
+		},
+		"same sender and other nonce": {
+			options:     []Option{WithReplaceByFee(10)},
+			old:         nonceTransaction(t, "alice", 1, 10),
+			new:         nonceTransaction(t, "alice", 2, 1),
+			expectedLen: 2,
+		},
+		"without replace-by-fee": {
+			old:              Transaction{Hash: "AB", Gas: 1, FeePerGas: "2", Signature: "CD"},
+			new:              Transaction{Hash: "AB", Gas: 1, FeePerGas: "1", Signature: "CD"},
+			expectedReplaced: true,
+			expectedLen:      1,
+		},
+	}
+
+	for tName, tc := range tests {
+		tc := tc
+		t.Run(tName, func(t *testing.T) {
+			memPool := NewMemPool(tc.options...)
+			mustPush(t, memPool, tc.old)
+
+			result, err := memPool.Push(tc.new)
+			require.Equal(t, tc.expectedLen, memPool.Len())
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				require.False(t, result.Admitted)
+				tx, _ := memPool.Peek()
+				require.Equal(t, tc.old.Hash, tx.Hash)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, result.Admitted)
+			if tc.expectedReplaced {
+				require.Equal(t, &tc.old, result.Replaced)
+			} else {
+				require.Nil(t, result.Replaced)
+			}
+		})
+	}
+}
+
+func TestMemPool_ReplaceByFeeErrorMessage(t *testing.T) {
+	memPool := NewMemPool(WithReplaceByFee(25))
+	mustPush(t, memPool, Transaction{Hash: "AB", Gas: 1, FeePerGas: "2", Signature: "CD"})
+
+	_, err := memPool.Push(Transaction{Hash: "AB", Gas: 1, FeePerGas: "2.4", Signature: "CD"})
+	require.EqualError(t, err, "Replacement transaction underpriced [AB], the fee must be at least 25% higher than the fee of [AB]")
+}
+
+func TestMemPool_PushBatchReplaceByFee(t *testing.T) {
+	memPool := NewMemPool(WithReplaceByFee(10), WithNonceOrdering())
+	mustPush(t, memPool, nonceTransaction(t, "alice", 0, 10))
+
+	// The second transaction outbids the first one, but not the one it replaces within the batch
+	err := memPool.PushBatch([]Transaction{
+		nonceTransaction(t, "alice", 0, 20),
+		nonceTransaction(t, "alice", 0, 21),
+	})
+	require.ErrorIs(t, err, ErrReplacementUnderpriced)
+	require.Equal(t, []string{"alice0-10"}, popAll(memPool))
+
+	mustPush(t, memPool, nonceTransaction(t, "alice", 1, 10))
+	require.NoError(t, memPool.PushBatch([]Transaction{
+		nonceTransaction(t, "alice", 1, 20),
+		{Hash: "alice1-20", Gas: 1, FeePerGas: "22", Signature: "CD"},
+		nonceTransaction(t, "alice", 1, 11),
+	}))
+	require.Equal(t, []string{"alice1-20", "alice1-11"}, popAll(memPool))
+}
+
+func TestMemPool_PushBatchReplaceByFeeWithoutNonceOrdering(t *testing.T) {
+	memPool := NewMemPool(WithReplaceByFee(10))
+	mustPush(t, memPool, nonceTransaction(t, "alice", 0, 10))
+
+	err := memPool.PushBatch([]Transaction{nonceTransaction(t, "alice", 0, 10)})
+	require.ErrorIs(t, err, ErrReplacementUnderpriced)
+
+	require.NoError(t, memPool.PushBatch([]Transaction{
+		nonceTransaction(t, "alice", 0, 20),
+		nonceTransaction(t, "bob", 0, 5),
+		nonceTransaction(t, "alice", 0, 30),
+	}))
+	require.Equal(t, []string{"alice0-30", "bob0-5"}, popAll(memPool))
+
+	// Once popped, the sender and the nonce don't conflict anymore
+	mustPush(t, memPool, nonceTransaction(t, "alice", 0, 1))
+	require.Equal(t, 1, memPool.Len())
+}
+
+func TestMemPool_PushBatchReplacesSenderNonce(t *testing.T) {
+	memPool := NewMemPool()
+	mustPush(t, memPool, Transaction{Hash: "AA", Gas: 1, FeePerGas: "10", Signature: "CD", Sender: "alice", Nonce: 4, HasNonce: true})
+	require.NoError(t, memPool.PushBatch([]Transaction{{Hash: "AA", Gas: 1, FeePerGas: "5", Signature: "CD"}}))
+
+	// The transaction of the batch has no sender, so the sender and the nonce are free again
+	result, err := memPool.Push(Transaction{Hash: "BB", Gas: 1, FeePerGas: "1", Signature: "CD", Sender: "alice", Nonce: 4, HasNonce: true})
+	require.NoError(t, err)
+	require.Nil(t, result.Replaced)
+	require.Equal(t, 2, memPool.Len())
+	require.True(t, memPool.Contains("AA"))
+	require.True(t, memPool.Contains("BB"))
+}
+
+func TestMemPool_ReadTransactionsReplaceByFee(t *testing.T) {
+	input := strings.Join([]string{
+		"TxHash=AB Gas=1 FeePerGas=10 Signature=CD",
+		"TxHash=AB Gas=1 FeePerGas=10.5 Signature=CD",
+		"TxHash=AB Gas=1 FeePerGas=11 Signature=CD",
+	}, "\n")
+
+	for _, atomic := range []bool{false, true} {
+		memPool := NewMemPool(WithReplaceByFee(10))
+		report, err := memPool.ReadTransactionsWith(strings.NewReader(input), ReadOptions{Lenient: true, Atomic: atomic})
+		require.NoError(t, err)
+		require.Equal(t, 2, report.Accepted)
+		require.Equal(t, 1, report.Rejected)
+		require.Equal(t, 2, report.Errors[0].Line)
+		require.ErrorIs(t, report.Errors[0], ErrReplacementUnderpriced)
+		tx, _ := memPool.Pop()
+		require.Equal(t, "11", tx.FeePerGas)
+
+		memPool = NewMemPool(WithReplaceByFee(10))
+		report, err = memPool.ReadTransactionsWith(strings.NewReader(input), ReadOptions{Atomic: atomic})
+		require.ErrorIs(t, err, ErrReplacementUnderpriced)
+		if atomic {
+			require.Equal(t, 0, memPool.Len())
+		} else {
+			require.Equal(t, 1, memPool.Len())
+		}
+	}
+}
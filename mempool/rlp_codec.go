@@ -0,0 +1,137 @@
+package mempool
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/prybintsev/memepool/rlp"
+)
+
+// RLPCodec encodes every transaction as the RLP list [hash, gas, fee per gas, signature, fields]
+// The hash and the signature are the bytes of their hex, so they must be hex strings and are decoded in upper case.
+// The gas is an integer, the fee per gas is the string of its decimal as it is written, empty if it is omitted by
+// a transaction with the dynamic fee fields, and the fields are the list of the [key, value] lists of the optional
+// fields, i.e. Sender, Nonce, the dynamic fee fields and the extension fields. The records follow each other without separators
+type RLPCodec struct{}
+
+func (RLPCodec) Name() string {
+	return "rlp"
+}
+
+func (RLPCodec) NewDecoder(reader io.Reader, options ParseOptions) Decoder {
+	return &rlpDecoder{stream: rlp.NewStream(reader, maxBinaryRecordSize), options: options}
+}
+
+func (RLPCodec) NewEncoder(writer io.Writer) Encoder {
+	return &rlpEncoder{writer: writer}
+}
+
+type rlpDecoder struct {
+	stream  *rlp.Stream
+	options ParseOptions
+	// number is the 1-based number of the last read record
+	number int
+	// record is the hex of the last read record
+	record string
+}
+
+func (d *rlpDecoder) Decode() (Transaction, error) {
+	item, err := d.stream.Next()
+	if err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return Transaction{}, err
+		}
+		return Transaction{}, fmt.Errorf("%w %d: %v", ErrMalformedRecord, d.number+1, err)
+	}
+	d.number++
+	d.record = hex.EncodeToString(item)
+
+	tokensMap, err := decodeRLPRecord(item)
+	if err != nil {
+		return Transaction{}, &ParseError{Line: d.number, Text: d.record, Err: ErrMalformedRecord, detail: err.Error()}
+	}
+	tx, err := parseTokens(tokensMap, d.record, d.options)
+	if err != nil {
+		return tx, atLine(err, d.number, d.record)
+	}
+	return tx, nil
+}
+
+func (d *rlpDecoder) Record() (int, string) {
+	return d.number, d.record
+}
+
+// decodeRLPRecord decodes the fields of the record into the tokens, so they are validated like the text ones
+func decodeRLPRecord(item []byte) (map[string]token, error) {
+	record, err := rlp.Decode(item)
+	if err != nil {
+		return nil, err
+	}
+	if record.Kind != rlp.List || len(record.List) != 5 {
+		return nil, errors.New("expected list of 5 items")
+	}
+	for i, value := range record.List[:4] {
+		if value.Kind != rlp.String {
+			return nil, fmt.Errorf("item %d: %w", i, rlp.ErrExpectedString)
+		}
+	}
+
+	tokensMap := make(map[string]token)
+	tokensMap[KeyHash] = token{key: KeyHash, value: strings.ToUpper(hex.EncodeToString(record.List[0].Bytes))}
+	gas, err := record.List[1].Uint64()
+	if err != nil {
+		return nil, fmt.Errorf("gas: %w", err)
+	}
+	tokensMap[KeyGas] = token{key: KeyGas, value: strconv.FormatUint(gas, 10)}
+	if fee := string(record.List[2].Bytes); fee != "" {
+		tokensMap[KeyFee] = token{key: KeyFee, value: fee}
+	}
+	tokensMap[KeySignature] = token{key: KeySignature, value: strings.ToUpper(hex.EncodeToString(record.List[3].Bytes))}
+
+	fields := record.List[4]
+	if fields.Kind != rlp.List {
+		return nil, fmt.Errorf("fields: %w", rlp.ErrExpectedList)
+	}
+	for _, field := range fields.List {
+		if field.Kind != rlp.List || len(field.List) != 2 || field.List[0].Kind != rlp.String || field.List[1].Kind != rlp.String {
+			return nil, errors.New("field: expected list of key and value strings")
+		}
+		key := string(field.List[0].Bytes)
+		addToken(tokensMap, token{key: key, value: string(field.List[1].Bytes)})
+	}
+	return tokensMap, nil
+}
+
+type rlpEncoder struct {
+	writer io.Writer
+}
+
+func (e *rlpEncoder) Encode(tx Transaction) error {
+	hash, err := hex.DecodeString(tx.Hash)
+	if err != nil {
+		return fmt.Errorf("%w for field %s [%s]", ErrInvalidHex, KeyHash, tx.Hash)
+	}
+	signature, err := hex.DecodeString(tx.Signature)
+	if err != nil {
+		return fmt.Errorf("%w for field %s [%s]", ErrInvalidHex, KeySignature, tx.Signature)
+	}
+
+	fields := []interface{}{}
+	for _, field := range optionalFields(tx) {
+		fields = append(fields, []interface{}{field.Key, field.Value})
+	}
+	record, err := rlp.Encode([]interface{}{hash, tx.Gas, tx.FeePerGas, signature, fields})
+	if err != nil {
+		return err
+	}
+	_, err = e.writer.Write(record)
+	return err
+}
+
+func (e *rlpEncoder) Close() error {
+	return nil
+}
@@ -0,0 +1,104 @@
+package mempool
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/prybintsev/memepool/rlp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRLPCodec_Encode(t *testing.T) {
+	var buf bytes.Buffer
+	encoder := RLPCodec{}.NewEncoder(&buf)
+	require.NoError(t, encoder.Encode(Transaction{Hash: "ab", Gas: 1000, FeePerGas: "0.5", Signature: "CD01", Sender: "alice"}))
+	require.NoError(t, encoder.Close())
+	require.Equal(t, "db81ab8203e883302e3582cd01cecd8653656e64657285616c696365", hex.EncodeToString(buf.Bytes()))
+
+	tx, err := RLPCodec{}.NewDecoder(&buf, ParseOptions{}).Decode()
+	require.NoError(t, err)
+	require.Equal(t, "TxHash=AB Gas=1000 FeePerGas=0.5 Signature=CD01 Sender=alice", tx.String())
+}
+
+func TestRLPCodec_Extensions(t *testing.T) {
+	expected := Transaction{Hash: "AB", Gas: 1, FeePerGas: "1", Signature: "CD", Sender: "bob", Extensions: []Field{{Key: "Chain", Value: "4"}, {Key: "Memo", Value: "x"}}}
+	var buf bytes.Buffer
+	require.NoError(t, RLPCodec{}.NewEncoder(&buf).Encode(expected))
+
+	tx, err := RLPCodec{}.NewDecoder(&buf, ParseOptions{}).Decode()
+	require.NoError(t, err)
+	require.Equal(t, expected.String(), tx.String())
+	require.Equal(t, expected.Extensions, tx.Extensions)
+}
+
+func TestRLPCodec_EncodeInvalidHex(t *testing.T) {
+	encoder := RLPCodec{}.NewEncoder(io.Discard)
+	require.ErrorIs(t, encoder.Encode(Transaction{Hash: "XY", Gas: 1, FeePerGas: "1", Signature: "CD"}), ErrInvalidHex)
+	require.ErrorIs(t, encoder.Encode(Transaction{Hash: "AB", Gas: 1, FeePerGas: "1", Signature: "C"}), ErrInvalidHex)
+}
+
+func TestRLPCodec_DecodeMalformedRecord(t *testing.T) {
+	valid := func(fee string) []byte {
+		record, err := rlp.Encode([]interface{}{[]byte{0xAB}, 1, fee, []byte{0xCD}, []interface{}{}})
+		require.NoError(t, err)
+		return record
+	}
+	tests := map[string][]byte{
+		"not a list":        rlp.EncodeBytes([]byte("AB")),
+		"missing fields":    rlp.EncodeList(rlp.EncodeBytes([]byte{0xAB}), rlp.EncodeUint(1)),
+		"list as gas":       rlp.EncodeList(rlp.EncodeBytes([]byte{0xAB}), rlp.EncodeList(), rlp.EncodeBytes([]byte("1")), rlp.EncodeBytes([]byte{0xCD}), rlp.EncodeList()),
+		"non-canonical gas": rlp.EncodeList(rlp.EncodeBytes([]byte{0xAB}), rlp.EncodeBytes([]byte{0, 1}), rlp.EncodeBytes([]byte("1")), rlp.EncodeBytes([]byte{0xCD}), rlp.EncodeList()),
+		"invalid field":     rlp.EncodeList(rlp.EncodeBytes([]byte{0xAB}), rlp.EncodeUint(1), rlp.EncodeBytes([]byte("1")), rlp.EncodeBytes([]byte{0xCD}), rlp.EncodeList(rlp.EncodeList(rlp.EncodeBytes([]byte("Sender"))))),
+	}
+
+	for tName, record := range tests {
+		record := record
+		t.Run(tName, func(t *testing.T) {
+			decoder := RLPCodec{}.NewDecoder(bytes.NewReader(append(record, valid("2")...)), ParseOptions{})
+			_, err := decoder.Decode()
+			require.ErrorIs(t, err, ErrMalformedRecord)
+			var parseErr *ParseError
+			require.ErrorAs(t, err, &parseErr)
+			require.Equal(t, 1, parseErr.Line)
+			require.Equal(t, hex.EncodeToString(record), parseErr.Text)
+
+			tx, err := decoder.Decode()
+			require.NoError(t, err)
+			require.Equal(t, "2", tx.FeePerGas)
+		})
+	}
+
+	_, err := RLPCodec{}.NewDecoder(bytes.NewReader(valid("x")), ParseOptions{}).Decode()
+	require.ErrorIs(t, err, ErrInvalidValueForField)
+}
+
+func TestRLPCodec_DecodeTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, RLPCodec{}.NewEncoder(&buf).Encode(testTransaction(t, 1)))
+
+	_, err := RLPCodec{}.NewDecoder(bytes.NewReader(buf.Bytes()[:buf.Len()-1]), ParseOptions{}).Decode()
+	require.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestRLPCodec_RoundTrip(t *testing.T) {
+	input, err := os.ReadFile("../transactions.txt")
+	require.NoError(t, err)
+
+	memPool := NewMemPool(WithTieBreak(TieBreakArrival), WithCapacity(10000))
+	require.NoError(t, memPool.ReadTransactions(bytes.NewReader(input)))
+	var rlpOutput bytes.Buffer
+	require.NoError(t, memPool.WriteTransactionsWith(&rlpOutput, RLPCodec{}))
+
+	rlpPool := NewMemPool(WithTieBreak(TieBreakArrival), WithCapacity(10000))
+	report, err := rlpPool.ReadTransactionsWith(&rlpOutput, ReadOptions{Codec: RLPCodec{}})
+	require.NoError(t, err)
+	require.Equal(t, 7500, report.Accepted)
+
+	var expected, actual bytes.Buffer
+	require.NoError(t, memPool.WriteTransactions(&expected))
+	require.NoError(t, rlpPool.WriteTransactions(&actual))
+	require.Equal(t, expected.String(), actual.String())
+}
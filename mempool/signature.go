@@ -0,0 +1,178 @@
+package mempool
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+)
+
+const KeyPublicKey = "PublicKey"
+
+var (
+	ErrUnknownSender    = errors.New("Unknown sender")
+	ErrInvalidSignature = errors.New("Invalid signature")
+)
+
+// SignatureVerifier checks that the signature of the transaction is produced by its sender
+type SignatureVerifier interface {
+	VerifySignature(tx Transaction) error
+}
+
+// VerificationPolicy defines what the pool does with the transactions which signatures don't verify
+type VerificationPolicy int
+
+const (
+	// RejectInvalid makes ReadTransactions fail on the first transaction with an invalid signature
+	RejectInvalid VerificationPolicy = iota
+	// QuarantineInvalid keeps the transactions with invalid signatures aside of the pool, see MemPool.Quarantined
+	QuarantineInvalid
+)
+
+// Ed25519Verifier verifies ed25519 signatures of the transaction hash with the public keys of the senders
+type Ed25519Verifier struct {
+	Keys map[string]ed25519.PublicKey
+}
+
+func (v Ed25519Verifier) VerifySignature(tx Transaction) error {
+	key, ok := v.Keys[tx.Sender]
+	if !ok {
+		return fmt.Errorf("%w [%s]", ErrUnknownSender, tx.Sender)
+	}
+	hash, signature, err := decodeSigned(tx)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(key, hash, signature) {
+		return fmt.Errorf("%w for transaction %s", ErrInvalidSignature, tx.Hash)
+	}
+	return nil
+}
+
+// ECDSAVerifier verifies ECDSA signatures of the transaction hash with the public keys of the senders
+// The signature is the concatenation of big-endian r and s, each of them padded to the byte size of the curve,
+// so it is 64 bytes long for P-256
+type ECDSAVerifier struct {
+	Keys map[string]*ecdsa.PublicKey
+}
+
+func (v ECDSAVerifier) VerifySignature(tx Transaction) error {
+	key, ok := v.Keys[tx.Sender]
+	if !ok {
+		return fmt.Errorf("%w [%s]", ErrUnknownSender, tx.Sender)
+	}
+	hash, signature, err := decodeSigned(tx)
+	if err != nil {
+		return err
+	}
+	size := (key.Curve.Params().BitSize + 7) / 8
+	if len(signature) != 2*size {
+		return fmt.Errorf("%w for transaction %s", ErrInvalidSignature, tx.Hash)
+	}
+	r := new(big.Int).SetBytes(signature[:size])
+	s := new(big.Int).SetBytes(signature[size:])
+	if !ecdsa.Verify(key, hash, r, s) {
+		return fmt.Errorf("%w for transaction %s", ErrInvalidSignature, tx.Hash)
+	}
+	return nil
+}
+
+// decodeSigned decodes the hash which is signed and the signature of the transaction
+func decodeSigned(tx Transaction) (hash []byte, signature []byte, err error) {
+	hash, err = hex.DecodeString(tx.Hash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w for transaction %s: hash is not hex", ErrInvalidSignature, tx.Hash)
+	}
+	signature, err = hex.DecodeString(tx.Signature)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w for transaction %s: signature is not hex", ErrInvalidSignature, tx.Hash)
+	}
+	return hash, signature, nil
+}
+
+// KeyRegistry maps the senders to their ed25519 or ECDSA public keys
+type KeyRegistry struct {
+	ed25519 Ed25519Verifier
+	ecdsa   ECDSAVerifier
+}
+
+func NewKeyRegistry() KeyRegistry {
+	return KeyRegistry{
+		ed25519: Ed25519Verifier{Keys: make(map[string]ed25519.PublicKey)},
+		ecdsa:   ECDSAVerifier{Keys: make(map[string]*ecdsa.PublicKey)},
+	}
+}
+
+// ReadKeyRegistry reads the registry with a line per sender in the format
+// Sender=<sender> PublicKey=<hex of the DER encoded PKIX public key>
+func ReadKeyRegistry(reader io.Reader) (KeyRegistry, error) {
+	registry := NewKeyRegistry()
+
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		if err := registry.addFromLine(line); err != nil {
+			return registry, atLine(err, lineNumber, line)
+		}
+	}
+
+	return registry, scanner.Err()
+}
+
+// addFromLine registers the key from the line of the registry file
+func (r KeyRegistry) addFromLine(line string) error {
+	tokensMap, err := readTokens(line, true)
+	if err != nil {
+		return err
+	}
+	sender, ok := tokensMap[KeySender]
+	if !ok {
+		return fieldNotFound(KeySender, line)
+	}
+	keyToken, ok := tokensMap[KeyPublicKey]
+	if !ok {
+		return fieldNotFound(KeyPublicKey, line)
+	}
+	der, err := hex.DecodeString(keyToken.value)
+	if err != nil {
+		return keyToken.error(ErrInvalidValueForField, line, "")
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return keyToken.error(ErrInvalidValueForField, line, "")
+	}
+	return r.Add(sender.value, key)
+}
+
+// Add registers the public key of the sender, the key must be ed25519.PublicKey or *ecdsa.PublicKey
+func (r KeyRegistry) Add(sender string, key interface{}) error {
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		r.ed25519.Keys[sender] = k
+		delete(r.ecdsa.Keys, sender)
+	case *ecdsa.PublicKey:
+		r.ecdsa.Keys[sender] = k
+		delete(r.ed25519.Keys, sender)
+	default:
+		return fmt.Errorf("Unsupported public key type %T of sender %s", key, sender)
+	}
+	return nil
+}
+
+func (r KeyRegistry) VerifySignature(tx Transaction) error {
+	if _, ok := r.ed25519.Keys[tx.Sender]; ok {
+		return r.ed25519.VerifySignature(tx)
+	}
+	return r.ecdsa.VerifySignature(tx)
+}
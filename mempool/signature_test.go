@@ -0,0 +1,140 @@
+package mempool
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func readTestKeyRegistry(t *testing.T) KeyRegistry {
+	file, err := os.Open("testdata/keys.txt")
+	require.NoError(t, err)
+	defer file.Close()
+
+	registry, err := ReadKeyRegistry(file)
+	require.NoError(t, err)
+	return registry
+}
+
+func readTestTransactions(t *testing.T, path string) []Transaction {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var txs []Transaction
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		tx, err := ReadTransaction(scanner.Text())
+		require.NoError(t, err)
+		txs = append(txs, tx)
+	}
+	require.NoError(t, scanner.Err())
+	return txs
+}
+
+func TestKeyRegistry_VerifySignature(t *testing.T) {
+	registry := readTestKeyRegistry(t)
+
+	for _, tx := range readTestTransactions(t, "testdata/signed-transactions.txt") {
+		require.NoError(t, registry.VerifySignature(tx), "transaction %s of %s", tx.Hash, tx.Sender)
+	}
+
+	invalid := readTestTransactions(t, "testdata/invalid-signed-transactions.txt")
+	require.Len(t, invalid, 4)
+	// signed by another sender
+	require.ErrorIs(t, registry.VerifySignature(invalid[0]), ErrInvalidSignature)
+	// tampered signature
+	require.ErrorIs(t, registry.VerifySignature(invalid[1]), ErrInvalidSignature)
+	// unknown sender
+	require.ErrorIs(t, registry.VerifySignature(invalid[2]), ErrUnknownSender)
+	// tampered hash
+	require.ErrorIs(t, registry.VerifySignature(invalid[3]), ErrInvalidSignature)
+}
+
+func TestReadKeyRegistry_Errors(t *testing.T) {
+	tests := map[string]struct {
+		input         string
+		expectedError string
+	}{
+		"missing sender": {
+			input:         "PublicKey=AB",
+			expectedError: "Line 1: Field Sender not found in line [PublicKey=AB]",
+		},
+		"missing key": {
+			input:         "Sender=alice",
+			expectedError: "Line 1: Field PublicKey not found in line [Sender=alice]",
+		},
+		"non hex key": {
+			input:         "Sender=alice PublicKey=XYZ",
+			expectedError: "Line 1, column 14: Invalid value for field PublicKey [XYZ]",
+		},
+		"not a public key": {
+			input:         "Sender=alice PublicKey=ABCD",
+			expectedError: "Line 1, column 14: Invalid value for field PublicKey [ABCD]",
+		},
+	}
+
+	for tName, tc := range tests {
+		tc := tc
+		t.Run(tName, func(t *testing.T) {
+			_, err := ReadKeyRegistry(strings.NewReader(tc.input))
+			require.EqualError(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestEd25519Verifier(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hash := sha256.Sum256([]byte("transaction"))
+	tx := Transaction{
+		Hash:      fmt.Sprintf("%X", hash),
+		Signature: fmt.Sprintf("%X", ed25519.Sign(private, hash[:])),
+		Sender:    "alice",
+	}
+	verifier := Ed25519Verifier{Keys: map[string]ed25519.PublicKey{"alice": public}}
+
+	require.NoError(t, verifier.VerifySignature(tx))
+
+	tx.Sender = "bob"
+	require.ErrorIs(t, verifier.VerifySignature(tx), ErrUnknownSender)
+
+	tx.Sender = "alice"
+	tx.Signature = "not hex"
+	require.ErrorIs(t, verifier.VerifySignature(tx), ErrInvalidSignature)
+}
+
+func TestECDSAVerifier(t *testing.T) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	hash := sha256.Sum256([]byte("transaction"))
+	r, s, err := ecdsa.Sign(rand.Reader, private, hash[:])
+	require.NoError(t, err)
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	tx := Transaction{
+		Hash:      fmt.Sprintf("%X", hash),
+		Signature: fmt.Sprintf("%X", signature),
+		Sender:    "carol",
+	}
+	verifier := ECDSAVerifier{Keys: map[string]*ecdsa.PublicKey{"carol": &private.PublicKey}}
+
+	require.NoError(t, verifier.VerifySignature(tx))
+
+	tx.Signature = fmt.Sprintf("%X", signature[:63])
+	require.ErrorIs(t, verifier.VerifySignature(tx), ErrInvalidSignature)
+
+	signature[0] ^= 1
+	tx.Signature = fmt.Sprintf("%X", signature)
+	require.ErrorIs(t, verifier.VerifySignature(tx), ErrInvalidSignature)
+}
@@ -0,0 +1,61 @@
+package mempool
+
+import "io"
+
+// TextCodec is the default codec with a line of whitespace separated Key=Value tokens per transaction,
+// see Transaction.String and ParseTransaction
+// The encoded lines are separated by line breaks without one after the last line, the blank lines are skipped when decoding
+type TextCodec struct{}
+
+func (TextCodec) Name() string {
+	return "text"
+}
+
+func (TextCodec) NewDecoder(reader io.Reader, options ParseOptions) Decoder {
+	return &textDecoder{lines: newLineReader(reader), options: options}
+}
+
+func (TextCodec) NewEncoder(writer io.Writer) Encoder {
+	return &textEncoder{writer: writer}
+}
+
+type textDecoder struct {
+	lines   *lineReader
+	options ParseOptions
+}
+
+func (d *textDecoder) Decode() (Transaction, error) {
+	line, err := d.lines.next()
+	if err != nil {
+		return Transaction{}, err
+	}
+	tx, err := ParseTransaction(line, d.options)
+	if err != nil {
+		return tx, atLine(err, d.lines.number, line)
+	}
+	return tx, nil
+}
+
+func (d *textDecoder) Record() (int, string) {
+	return d.lines.number, d.lines.line
+}
+
+type textEncoder struct {
+	writer io.Writer
+	count  int
+}
+
+func (e *textEncoder) Encode(tx Transaction) error {
+	if e.count > 0 {
+		if _, err := io.WriteString(e.writer, "\n"); err != nil {
+			return err
+		}
+	}
+	e.count++
+	_, err := io.WriteString(e.writer, tx.String())
+	return err
+}
+
+func (e *textEncoder) Close() error {
+	return nil
+}
@@ -0,0 +1,53 @@
+package mempool
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextCodec_Decode(t *testing.T) {
+	input := "TxHash=AB Gas=1 FeePerGas=1 Signature=CD\n\ninvalid\nTxHash=EF Gas=2 FeePerGas=0.5 Signature=CD Sender=alice\n"
+	decoder := TextCodec{}.NewDecoder(strings.NewReader(input), ParseOptions{})
+
+	tx, err := decoder.Decode()
+	require.NoError(t, err)
+	require.Equal(t, "TxHash=AB Gas=1 FeePerGas=1 Signature=CD", tx.String())
+
+	_, err = decoder.Decode()
+	var parseErr *ParseError
+	require.ErrorAs(t, err, &parseErr)
+	require.Equal(t, 3, parseErr.Line)
+	require.ErrorIs(t, err, ErrInvalidToken)
+	number, text := decoder.Record()
+	require.Equal(t, 3, number)
+	require.Equal(t, "invalid", text)
+
+	tx, err = decoder.Decode()
+	require.NoError(t, err)
+	require.Equal(t, "alice", tx.Sender)
+	number, _ = decoder.Record()
+	require.Equal(t, 4, number)
+
+	_, err = decoder.Decode()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestTextCodec_DecodeStrict(t *testing.T) {
+	decoder := TextCodec{}.NewDecoder(strings.NewReader("TxHash=AB Gas=1 FeePerGas=1 Signature=CD"), ParseOptions{StrictEncoding: true})
+	_, err := decoder.Decode()
+	require.ErrorIs(t, err, ErrInvalidLength)
+}
+
+func TestTextCodec_Encode(t *testing.T) {
+	var buf bytes.Buffer
+	encoder := TextCodec{}.NewEncoder(&buf)
+	require.NoError(t, encoder.Encode(Transaction{Hash: "AB", Gas: 1, FeePerGas: "1", Signature: "CD"}))
+	require.NoError(t, encoder.Encode(Transaction{Hash: "EF", Gas: 2, FeePerGas: "0.5", Signature: "CD"}))
+	require.NoError(t, encoder.Close())
+
+	require.Equal(t, "TxHash=AB Gas=1 FeePerGas=1 Signature=CD\nTxHash=EF Gas=2 FeePerGas=0.5 Signature=CD", buf.String())
+}
@@ -1,10 +1,14 @@
 package mempool
 
 import (
-	"errors"
 	"fmt"
+	"hash"
+	"math/big"
+	"sort"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 const (
@@ -12,10 +16,10 @@ const (
 	KeyGas = "Gas"
 	KeyFee = "FeePerGas"
 	KeySignature = "Signature"
-
-	ErrFieldNotFound = "Field %s not found in line [%s]"
-	ErrInvalidToken = "Invalid token [%s]"
-	ErrInvalidValueForField = "Invalid value for field %s [%s]"
+	KeySender = "Sender"
+	KeyNonce = "Nonce"
+	KeyMaxFee = "MaxFeePerGas"
+	KeyMaxPriorityFee = "MaxPriorityFeePerGas"
 )
 
 type Transaction struct {
@@ -25,79 +29,368 @@ type Transaction struct {
 	// due to float type not being able to accurately represent decimal fraction
 	FeePerGas string
 	Signature string
+	// Sender is optional, it identifies the key which the signature is verified with
+	Sender string
+	// Nonce is optional and set only if HasNonce is true, it orders the transactions of the sender
+	Nonce    uint64
+	HasNonce bool
+	// MaxFeePerGas and MaxPriorityFeePerGas are optional and set together, they make the fee depend on the base fee
+	// of the pool as in EIP-1559, see EffectiveTip. FeePerGas may be empty for such a transaction and is ignored otherwise
+	MaxFeePerGas         string
+	MaxPriorityFeePerGas string
+	// Extensions are the other fields of the transaction in the order they were read, they are kept as they are
+	Extensions []Field
+
+	// The exact values of the fee per gas and the total fee
+	feePerGasNumeric *big.Rat
+	totalFee *big.Rat
+	// The exact values of the dynamic fee fields
+	maxFeeNumeric         *big.Rat
+	maxPriorityFeeNumeric *big.Rat
 
-	feePerGasNumeric float64
-	totalFee float64
+	// The decoded hash and signature, only set when the transaction is parsed with strict encoding
+	hashBytes      [HashSize]byte
+	signatureBytes [SignatureSize]byte
+	decoded        bool
 }
 
+// Priority returns the total fee of the transaction as float
+// The value is approximate, the transactions are compared by the exact value returned by Fee.
+// The pool with a base fee compares the transactions by their effective tips instead
 func (t Transaction) Priority() float64 {
-	return t.totalFee
+	fee, _ := t.exactFee().Float64()
+	return fee
+}
+
+// Fee returns the exact total fee of the transaction
+// For a transaction with the dynamic fee fields it is the total of the max priority fee, i.e. the tip without a base fee
+func (t Transaction) Fee() *big.Rat {
+	return new(big.Rat).Set(t.exactFee())
+}
+
+// exactFee returns the total fee without copying it, so it must not be modified
+// For a transaction which was not created by ReadTransaction the fee is calculated from the fee fields
+func (t Transaction) exactFee() *big.Rat {
+	if t.totalFee != nil {
+		return t.totalFee
+	}
+	tip, _ := t.EffectiveTip(nil)
+	return tip.Mul(tip, new(big.Rat).SetInt64(int64(t.Gas)))
 }
 
-func (t Transaction) Fee() float64 {
-	return t.feePerGasNumeric * float64(t.Gas)
+// Field is a Key=Value field of the transaction which is not interpreted by the pool
+type Field struct {
+	Key   string
+	Value string
+}
+
+// Extension returns the value of the extension field with the given key
+func (t Transaction) Extension(key string) (string, bool) {
+	for _, field := range t.Extensions {
+		if field.Key == key {
+			return field.Value, true
+		}
+	}
+	return "", false
 }
 
 func (t Transaction) String() string {
-	format := fmt.Sprintf("%s=%%s %s=%%v %s=%%s %s=%%s", KeyHash, KeyGas, KeyFee, KeySignature)
-	return fmt.Sprintf(format, t.Hash, t.Gas, t.FeePerGas, t.Signature)
+	s := fmt.Sprintf("%s=%s %s=%v", KeyHash, t.Hash, KeyGas, t.Gas)
+	if t.hasFeePerGas() {
+		s += fmt.Sprintf(" %s=%s", KeyFee, t.FeePerGas)
+	}
+	s += fmt.Sprintf(" %s=%s", KeySignature, t.Signature)
+	for _, field := range optionalFields(t) {
+		s += fmt.Sprintf(" %s=%s", field.Key, field.Value)
+	}
+	return s
+}
+
+// optionalFields returns the fields which are written only when they are set followed by the extension fields
+func optionalFields(tx Transaction) []Field {
+	var fields []Field
+	if tx.Sender != "" {
+		fields = append(fields, Field{Key: KeySender, Value: tx.Sender})
+	}
+	if tx.HasNonce {
+		fields = append(fields, Field{Key: KeyNonce, Value: strconv.FormatUint(tx.Nonce, 10)})
+	}
+	if tx.MaxFeePerGas != "" {
+		fields = append(fields, Field{Key: KeyMaxFee, Value: tx.MaxFeePerGas})
+	}
+	if tx.MaxPriorityFeePerGas != "" {
+		fields = append(fields, Field{Key: KeyMaxPriorityFee, Value: tx.MaxPriorityFeePerGas})
+	}
+	return append(fields, tx.Extensions...)
+}
+
+// isTransactionField reports whether the key is one of the fields interpreted by the pool
+func isTransactionField(key string) bool {
+	switch key {
+	case KeyHash, KeyGas, KeyFee, KeySignature, KeySender, KeyNonce, KeyMaxFee, KeyMaxPriorityFee:
+		return true
+	}
+	return false
+}
+
+// isRequiredField reports whether every transaction has the field, FeePerGas may be replaced by the dynamic fee fields
+func isRequiredField(key string) bool {
+	switch key {
+	case KeyHash, KeyGas, KeySignature:
+		return true
+	}
+	return false
+}
+
+// ParseOptions enables the optional validations of the parsed transactions
+type ParseOptions struct {
+	// StrictEncoding makes the parser reject the lines with duplicate keys and require TxHash and Signature
+	// to be hex strings of HashSize and SignatureSize bytes in either case
+	StrictEncoding bool
+	// VerifyHash makes the parser recompute the hash of the transaction contents and reject the transaction
+	// if it doesn't match TxHash
+	VerifyHash bool
+	// HashFunc is the hash function used for the verification, SHA-256 is used if it is nil
+	HashFunc func() hash.Hash
+	// RejectUnknownFields makes the parser reject the transactions with extension fields
+	RejectUnknownFields bool
 }
 
 func ReadTransaction(line string) (Transaction, error) {
-	var tx Transaction
-	var err error
-	tokens := strings.Fields(line)
+	return ParseTransaction(line, ParseOptions{})
+}
 
-	tokensMap := make(map[string]string)
-	for _, token := range tokens {
-		keyVal := strings.Split(token, "=")
-		if len(keyVal) != 2 {
-			return tx, errors.New(fmt.Sprintf(ErrInvalidToken, token))
-		}
-		tokensMap[keyVal[0]] = keyVal[1]
+// ParseTransaction reads the transaction from the line and validates it according to the options
+// The returned error is a *ParseError
+func ParseTransaction(line string, options ParseOptions) (Transaction, error) {
+	tokensMap, err := readTokens(line, options.StrictEncoding)
+	if err != nil {
+		return Transaction{}, err
 	}
 
-	tx, err = transactionFromTokensMap(tokensMap, line)
+	return parseTokens(tokensMap, line, options)
+}
+
+// parseTokens creates the transaction from the tokens of the record and validates it according to the options
+func parseTokens(tokensMap map[string]token, line string, options ParseOptions) (Transaction, error) {
+	tx, err := transactionFromTokensMap(tokensMap, line)
 	if err != nil {
 		return tx, err
 	}
 
+	if err = options.validate(&tx, tokensMap, line); err != nil {
+		return tx, err
+	}
+
 	return tx, nil
 }
 
-func transactionFromTokensMap(tokensMap map[string]string, line string) (Transaction, error) {
+// validate runs the validations enabled by the options, the strictly validated fields are decoded into the transaction
+func (o ParseOptions) validate(tx *Transaction, tokensMap map[string]token, line string) error {
+	if o.RejectUnknownFields && len(tx.Extensions) > 0 {
+		return tokensMap[tx.Extensions[0].Key].error(ErrUnknownField, line, "")
+	}
+	if o.StrictEncoding {
+		if err := decodeHexField(tx.hashBytes[:], tokensMap[KeyHash], line); err != nil {
+			return err
+		}
+		if err := decodeHexField(tx.signatureBytes[:], tokensMap[KeySignature], line); err != nil {
+			return err
+		}
+		tx.decoded = true
+	}
+	if o.VerifyHash {
+		if computed, ok := tx.verifyHash(o.HashFunc); !ok {
+			return tokensMap[KeyHash].error(ErrHashMismatch, line, fmt.Sprintf("computed [%s]", computed))
+		}
+	}
+	return nil
+}
+
+// token is a Key=Value token of a line
+type token struct {
+	key   string
+	value string
+	// column is the 1-based byte offset of the token in the line, 0 if the record is not a line of text
+	column int
+	// index is the position of the field in the record, it keeps the order of the extension fields
+	index int
+}
+
+// addToken adds the token of the next field of the record
+// A repeated key takes the last value and keeps the position of its first occurrence, so the indexes are unique
+func addToken(tokensMap map[string]token, t token) {
+	t.index = len(tokensMap)
+	if previous, ok := tokensMap[t.key]; ok {
+		t.index = previous.index
+	}
+	tokensMap[t.key] = t
+}
+
+// error returns the parse error of the token value
+func (t token) error(err error, line string, detail string) *ParseError {
+	return &ParseError{Column: t.column, Field: t.key, Token: t.value, Text: line, Err: err, detail: detail}
+}
+
+// readTokens splits the line of whitespace separated Key=Value tokens into a map
+// If a key is repeated, either the last value is taken or an error is returned when rejectDuplicates is set
+func readTokens(line string, rejectDuplicates bool) (map[string]token, error) {
+	tokensMap := make(map[string]token)
+	for i := 0; i < len(line); {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		if unicode.IsSpace(r) {
+			i += size
+			continue
+		}
+
+		start := i
+		for i < len(line) {
+			r, size = utf8.DecodeRuneInString(line[i:])
+			if unicode.IsSpace(r) {
+				break
+			}
+			i += size
+		}
+		raw := line[start:i]
+
+		keyVal := strings.Split(raw, "=")
+		if len(keyVal) != 2 {
+			return nil, &ParseError{Column: start + 1, Token: raw, Text: line, Err: ErrInvalidToken}
+		}
+		if _, ok := tokensMap[keyVal[0]]; ok && rejectDuplicates {
+			return nil, &ParseError{Column: start + 1, Field: keyVal[0], Token: keyVal[1], Text: line, Err: ErrDuplicateKey}
+		}
+		addToken(tokensMap, token{key: keyVal[0], value: keyVal[1], column: start + 1})
+	}
+
+	return tokensMap, nil
+}
+
+func fieldNotFound(field string, line string) *ParseError {
+	return &ParseError{Field: field, Text: line, Err: ErrFieldNotFound}
+}
+
+func transactionFromTokensMap(tokensMap map[string]token, line string) (Transaction, error) {
 	var tx Transaction
 	var err error
-	var ok bool
 
-	tx.Hash, ok = tokensMap[KeyHash]
+	hashToken, ok := tokensMap[KeyHash]
 	if !ok {
-		return tx, errors.New(fmt.Sprintf(ErrFieldNotFound, KeyHash, line))
+		return tx, fieldNotFound(KeyHash, line)
 	}
+	tx.Hash = hashToken.value
 
-	gasStr, ok := tokensMap[KeyGas]
+	gasToken, ok := tokensMap[KeyGas]
 	if !ok {
-		return tx, errors.New(fmt.Sprintf(ErrFieldNotFound, KeyGas, line))
+		return tx, fieldNotFound(KeyGas, line)
 	}
 
-	tx.Gas, err = strconv.Atoi(gasStr)
+	tx.Gas, err = strconv.Atoi(gasToken.value)
 	if err != nil {
-		return tx, errors.New(fmt.Sprintf(ErrInvalidValueForField, KeyGas, gasStr))
+		return tx, gasToken.error(ErrInvalidValueForField, line, "")
+	}
+
+	if err = tx.readFees(tokensMap, line); err != nil {
+		return tx, err
 	}
 
-	tx.FeePerGas, ok = tokensMap[KeyFee]
+	signatureToken, ok := tokensMap[KeySignature]
 	if !ok {
-		return tx, errors.New(fmt.Sprintf(ErrFieldNotFound, KeyFee, line))
+		return tx, fieldNotFound(KeySignature, line)
 	}
-	if tx.feePerGasNumeric, err = strconv.ParseFloat(tx.FeePerGas, 64); err != nil {
-		return tx, errors.New(fmt.Sprintf(ErrInvalidValueForField, KeyFee, tx.FeePerGas))
+	tx.Signature = signatureToken.value
+
+	tx.Sender = tokensMap[KeySender].value
+
+	if nonceToken, ok := tokensMap[KeyNonce]; ok {
+		tx.Nonce, err = strconv.ParseUint(nonceToken.value, 10, 64)
+		if err != nil {
+			return tx, nonceToken.error(ErrInvalidValueForField, line, "")
+		}
+		tx.HasNonce = true
 	}
-	tx.totalFee = tx.feePerGasNumeric * float64(tx.Gas)
 
-	tx.Signature, ok = tokensMap[KeySignature]
-	if !ok {
-		return tx, errors.New(fmt.Sprintf(ErrFieldNotFound, KeySignature, line))
+	tx.Extensions, err = extensionFields(tokensMap, line)
+	if err != nil {
+		return tx, err
 	}
 
 	return tx, nil
-}
\ No newline at end of file
+}
+
+// extensionFields returns the fields which are not interpreted by the pool in the order of the record
+// The fields must be valid Key=Value tokens, so the transaction can be written as a line of text
+func extensionFields(tokensMap map[string]token, line string) ([]Field, error) {
+	var extensions []token
+	for key, t := range tokensMap {
+		if !isTransactionField(key) {
+			extensions = append(extensions, t)
+		}
+	}
+	if len(extensions) == 0 {
+		return nil, nil
+	}
+	sort.SliceStable(extensions, func(i, j int) bool {
+		return extensions[i].index < extensions[j].index
+	})
+
+	fields := make([]Field, 0, len(extensions))
+	for _, t := range extensions {
+		if !isTokenText(t.key) || t.key == "" {
+			return nil, &ParseError{Column: t.column, Field: t.key, Token: t.key, Text: line, Err: ErrInvalidToken}
+		}
+		if !isTokenText(t.value) {
+			return nil, t.error(ErrInvalidValueForField, line, "")
+		}
+		fields = append(fields, Field{Key: t.key, Value: t.value})
+	}
+	return fields, nil
+}
+
+// isTokenText reports whether the text can be the key or the value of a token
+func isTokenText(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool {
+		return r == '=' || unicode.IsSpace(r)
+	}) < 0
+}
+
+// readFees reads either FeePerGas or both dynamic fee fields, or all of them, and calculates the total fee
+func (t *Transaction) readFees(tokensMap map[string]token, line string) error {
+	feeToken, hasFee := tokensMap[KeyFee]
+	maxFeeToken, hasMaxFee := tokensMap[KeyMaxFee]
+	maxPriorityFeeToken, hasMaxPriorityFee := tokensMap[KeyMaxPriorityFee]
+	switch {
+	case !hasFee && !hasMaxFee && !hasMaxPriorityFee:
+		return fieldNotFound(KeyFee, line)
+	case hasMaxFee && !hasMaxPriorityFee:
+		return fieldNotFound(KeyMaxPriorityFee, line)
+	case !hasMaxFee && hasMaxPriorityFee:
+		return fieldNotFound(KeyMaxFee, line)
+	}
+
+	var ok bool
+	if hasFee {
+		t.FeePerGas = feeToken.value
+		if t.feePerGasNumeric, ok = parseDecimal(t.FeePerGas); !ok {
+			return feeToken.error(ErrInvalidValueForField, line, "")
+		}
+	}
+	if !hasMaxFee {
+		t.totalFee = new(big.Rat).Mul(t.feePerGasNumeric, new(big.Rat).SetInt64(int64(t.Gas)))
+		return nil
+	}
+
+	t.MaxFeePerGas = maxFeeToken.value
+	if t.maxFeeNumeric, ok = parseDecimal(t.MaxFeePerGas); !ok {
+		return maxFeeToken.error(ErrInvalidValueForField, line, "")
+	}
+	t.MaxPriorityFeePerGas = maxPriorityFeeToken.value
+	if t.maxPriorityFeeNumeric, ok = parseDecimal(t.MaxPriorityFeePerGas); !ok {
+		return maxPriorityFeeToken.error(ErrInvalidValueForField, line, "")
+	}
+	if t.maxPriorityFeeNumeric.Cmp(t.maxFeeNumeric) > 0 {
+		return maxPriorityFeeToken.error(ErrInvalidValueForField, line, "exceeds "+KeyMaxFee)
+	}
+	t.totalFee = new(big.Rat).Mul(t.maxPriorityFeeNumeric, new(big.Rat).SetInt64(int64(t.Gas)))
+	return nil
+}
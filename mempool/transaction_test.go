@@ -1,6 +1,7 @@
 package mempool
 
 import (
+	"math/big"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -18,8 +19,8 @@ func TestTransactions_Read(t *testing.T) {
 				Hash:      "40E10C7CF56A738C0B8AD4EE30EA8008C7B2334B3ADA195083F8CB18BD3911A0",
 				Gas:       729000,
 				FeePerGas: "0.11134106816568039",
-				feePerGasNumeric: 0.11134106816568039,
-				totalFee: 81167.63869278101,
+				feePerGasNumeric: testRat("0.11134106816568039"),
+				totalFee: testRat("81167.63869278100431"),
 				Signature: "6386A3893BEB6A5A64E0677F406634E791DEE78D49CF30581AE5281D4094E495E671647EF5E7FD2D207AB8EBA0EA693703E9C368402731BE99E81BDB748EA662",
 			},
 		},
@@ -29,8 +30,8 @@ func TestTransactions_Read(t *testing.T) {
 				Hash:      "40E10C7CF56A738C0B8AD4EE30EA8008C7B2334B3ADA195083F8CB18BD3911A0",
 				Gas:       729000,
 				FeePerGas: "0.11134106816568039",
-				feePerGasNumeric: 0.11134106816568039,
-				totalFee: 81167.63869278101,
+				feePerGasNumeric: testRat("0.11134106816568039"),
+				totalFee: testRat("81167.63869278100431"),
 				Signature: "6386A3893BEB6A5A64E0677F406634E791DEE78D49CF30581AE5281D4094E495E671647EF5E7FD2D207AB8EBA0EA693703E9C368402731BE99E81BDB748EA662",
 			},
 		},
@@ -85,4 +86,179 @@ func TestTransactions_Read(t *testing.T) {
 func TestTransaction_String(t *testing.T) {
 	tx := Transaction{Hash: "ABC123",Gas: 456, FeePerGas: "0.35", Signature: "test_signature"}
 	require.Equal(t, "TxHash=ABC123 Gas=456 FeePerGas=0.35 Signature=test_signature", tx.String())
-}
\ No newline at end of file
+}
+
+func TestTransaction_Sender(t *testing.T) {
+	line := "TxHash=ABC123 Gas=456 FeePerGas=0.35 Signature=test_signature Sender=alice"
+	tx, err := ReadTransaction(line)
+	require.NoError(t, err)
+	require.Equal(t, "alice", tx.Sender)
+	require.Equal(t, line, tx.String())
+}
+
+func TestTransaction_Nonce(t *testing.T) {
+	tests := map[string]struct {
+		line          string
+		expectedNonce uint64
+		expectedError string
+	}{
+		"zero nonce": {
+			line: "TxHash=ABC123 Gas=456 FeePerGas=0.35 Signature=test_signature Sender=alice Nonce=0",
+		},
+		"max nonce": {
+			line:          "TxHash=ABC123 Gas=456 FeePerGas=0.35 Signature=test_signature Nonce=18446744073709551615",
+			expectedNonce: 18446744073709551615,
+		},
+		"negative nonce": {
+			line:          "TxHash=ABC123 Gas=456 FeePerGas=0.35 Signature=test_signature Nonce=-1",
+			expectedError: "Invalid value for field Nonce [-1]",
+		},
+		"nonce overflow": {
+			line:          "TxHash=ABC123 Gas=456 FeePerGas=0.35 Signature=test_signature Nonce=18446744073709551616",
+			expectedError: "Invalid value for field Nonce [18446744073709551616]",
+		},
+	}
+
+	for tName, tc := range tests {
+		tc := tc
+		t.Run(tName, func(t *testing.T) {
+			tx, err := ReadTransaction(tc.line)
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, tx.HasNonce)
+			require.Equal(t, tc.expectedNonce, tx.Nonce)
+			require.Nil(t, tx.Extensions)
+			require.Equal(t, tc.line, tx.String())
+		})
+	}
+}
+
+func TestTransaction_DynamicFee(t *testing.T) {
+	tests := map[string]struct {
+		line          string
+		expectedFee   *big.Rat
+		expectedError string
+	}{
+		"without fee per gas": {
+			line:        "TxHash=ABC123 Gas=2 Signature=test_signature MaxFeePerGas=3 MaxPriorityFeePerGas=0.5",
+			expectedFee: big.NewRat(1, 1),
+		},
+		"with fee per gas": {
+			line:        "TxHash=ABC123 Gas=2 FeePerGas=7 Signature=test_signature MaxFeePerGas=3 MaxPriorityFeePerGas=3",
+			expectedFee: big.NewRat(6, 1),
+		},
+		"without any fee": {
+			line:          "TxHash=ABC123 Gas=2 Signature=test_signature",
+			expectedError: "Field FeePerGas not found in line [TxHash=ABC123 Gas=2 Signature=test_signature]",
+		},
+		"without max priority fee": {
+			line:          "TxHash=ABC123 Gas=2 Signature=test_signature MaxFeePerGas=3",
+			expectedError: "Field MaxPriorityFeePerGas not found in line [TxHash=ABC123 Gas=2 Signature=test_signature MaxFeePerGas=3]",
+		},
+		"invalid max fee": {
+			line:          "TxHash=ABC123 Gas=2 Signature=test_signature MaxFeePerGas=x MaxPriorityFeePerGas=1",
+			expectedError: "Invalid value for field MaxFeePerGas [x]",
+		},
+		"max priority fee exceeds max fee": {
+			line:          "TxHash=ABC123 Gas=2 Signature=test_signature MaxFeePerGas=1 MaxPriorityFeePerGas=1.5",
+			expectedError: "Invalid value for field MaxPriorityFeePerGas [1.5]: exceeds MaxFeePerGas",
+		},
+	}
+
+	for tName, tc := range tests {
+		tc := tc
+		t.Run(tName, func(t *testing.T) {
+			tx, err := ReadTransaction(tc.line)
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, tx.IsDynamicFee())
+			require.Zero(t, tc.expectedFee.Cmp(tx.Fee()))
+			require.Nil(t, tx.Extensions)
+			require.Equal(t, tc.line, tx.String())
+		})
+	}
+}
+
+func TestTransaction_Extensions(t *testing.T) {
+	line := "Chain=4 TxHash=ABC123 Gas=456 Memo= FeePerGas=0.35 Signature=test_signature Sender=alice Tag=a"
+	tx, err := ReadTransaction(line)
+	require.NoError(t, err)
+	require.Equal(t, []Field{{Key: "Chain", Value: "4"}, {Key: "Memo", Value: ""}, {Key: "Tag", Value: "a"}}, tx.Extensions)
+	require.Equal(t, "TxHash=ABC123 Gas=456 FeePerGas=0.35 Signature=test_signature Sender=alice Chain=4 Memo= Tag=a", tx.String())
+
+	chain, ok := tx.Extension("Chain")
+	require.True(t, ok)
+	require.Equal(t, "4", chain)
+	_, ok = tx.Extension("Sender")
+	require.False(t, ok)
+
+	_, err = ParseTransaction(line, ParseOptions{RejectUnknownFields: true})
+	require.ErrorIs(t, err, ErrUnknownField)
+	_, err = ParseTransaction("TxHash=ABC123 Gas=456 FeePerGas=0.35 Signature=test_signature Sender=alice", ParseOptions{RejectUnknownFields: true})
+	require.NoError(t, err)
+
+	// A repeated key takes the last value at the position of its first occurrence
+	for i := 0; i < 100; i++ {
+		tx, err = ReadTransaction("TxHash=ABC123 Gas=456 FeePerGas=0.35 Signature=test_signature Memo=1 Chain=4 Memo=2 Tag=a")
+		require.NoError(t, err)
+		require.Equal(t, []Field{{Key: "Memo", Value: "2"}, {Key: "Chain", Value: "4"}, {Key: "Tag", Value: "a"}}, tx.Extensions)
+	}
+}
+
+func testRat(s string) *big.Rat {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		panic("invalid rational number " + s)
+	}
+	return r
+}
+
+func TestTransaction_Fee(t *testing.T) {
+	tests := map[string]struct {
+		line        string
+		expectedFee *big.Rat
+	}{
+		"decimal fee": {
+			line:        "TxHash=AB Gas=729000 FeePerGas=0.11134106816568039 Signature=CD",
+			expectedFee: testRat("81167.63869278100431"),
+		},
+		"exponent fee": {
+			line:        "TxHash=AB Gas=748000 FeePerGas=9.556431783046658e-05 Signature=CD",
+			expectedFee: testRat("71.482109737189001840"),
+		},
+		"fee beyond float precision": {
+			line:        "TxHash=AB Gas=3 FeePerGas=0.100000000000000000001 Signature=CD",
+			expectedFee: testRat("0.300000000000000000003"),
+		},
+	}
+
+	for tName, tc := range tests {
+		tc := tc
+		t.Run(tName, func(t *testing.T) {
+			tx, err := ReadTransaction(tc.line)
+			require.NoError(t, err)
+			require.Zero(t, tc.expectedFee.Cmp(tx.Fee()), "expected %s, got %s", tc.expectedFee.RatString(), tx.Fee().RatString())
+		})
+	}
+}
+
+func TestTransaction_FeeWithoutParsing(t *testing.T) {
+	tx := Transaction{Hash: "ABC123", Gas: 456, FeePerGas: "0.35", Signature: "test_signature"}
+	require.Zero(t, testRat("159.6").Cmp(tx.Fee()))
+	require.Equal(t, 159.6, tx.Priority())
+
+	// Modifying the returned value doesn't affect the transaction
+	tx.Fee().SetInt64(1)
+	require.Zero(t, testRat("159.6").Cmp(tx.Fee()))
+}
+
+func TestTransaction_FractionFeeIsInvalid(t *testing.T) {
+	_, err := ReadTransaction("TxHash=AB Gas=1 FeePerGas=1/3 Signature=CD")
+	require.EqualError(t, err, "Invalid value for field FeePerGas [1/3]")
+}
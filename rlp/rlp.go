@@ -0,0 +1,293 @@
+// Package rlp implements the Recursive Length Prefix encoding used by Ethereum
+// An item is either a byte string or a list of items, the integers are encoded as big-endian byte strings
+// without leading zeros
+package rlp
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+const (
+	// The offsets of the first byte of the encoding of the short and the long strings and lists
+	offsetShortString = 0x80
+	offsetLongString  = 0xB7
+	offsetShortList   = 0xC0
+	offsetLongList    = 0xF7
+	// maxShortSize is the maximum size of the payload which length is encoded in the first byte
+	maxShortSize = 55
+)
+
+var (
+	ErrNonCanonicalSize    = errors.New("Non-canonical size")
+	ErrNonCanonicalInteger = errors.New("Non-canonical integer")
+	ErrTrailingBytes       = errors.New("Trailing bytes after the item")
+	ErrNegativeInteger     = errors.New("Negative integer")
+	ErrUnsupportedType     = errors.New("Unsupported type")
+	ErrExpectedString      = errors.New("Expected string")
+	ErrExpectedList        = errors.New("Expected list")
+	ErrUint64Overflow      = errors.New("Integer overflows uint64")
+	// ErrUnexpectedEnd means that the input ends in the middle of an item
+	ErrUnexpectedEnd = errors.New("Unexpected end of input")
+	ErrItemTooLarge  = errors.New("Item is too large")
+)
+
+// Kind tells whether the item is a byte string or a list
+type Kind int
+
+const (
+	String Kind = iota
+	List
+)
+
+// Value is a decoded item
+type Value struct {
+	Kind Kind
+	// Bytes is the content of the string
+	Bytes []byte
+	// List are the items of the list
+	List []Value
+}
+
+// Uint64 returns the integer encoded by the string
+func (v Value) Uint64() (uint64, error) {
+	b, err := v.integerBytes()
+	if err != nil {
+		return 0, err
+	}
+	if len(b) > 8 {
+		return 0, ErrUint64Overflow
+	}
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+	return u, nil
+}
+
+// BigInt returns the integer encoded by the string
+func (v Value) BigInt() (*big.Int, error) {
+	b, err := v.integerBytes()
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// integerBytes returns the big-endian bytes of the integer checking that there are no leading zeros
+func (v Value) integerBytes() ([]byte, error) {
+	if v.Kind != String {
+		return nil, ErrExpectedString
+	}
+	if len(v.Bytes) > 0 && v.Bytes[0] == 0 {
+		return nil, ErrNonCanonicalInteger
+	}
+	return v.Bytes, nil
+}
+
+// Encode encodes the value, which can be a Value, []byte, string, an unsigned or a non-negative signed integer,
+// *big.Int or []interface{} of these types
+func Encode(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case Value:
+		return encodeValue(v), nil
+	case []byte:
+		return EncodeBytes(v), nil
+	case string:
+		return EncodeBytes([]byte(v)), nil
+	case uint:
+		return EncodeUint(uint64(v)), nil
+	case uint8:
+		return EncodeUint(uint64(v)), nil
+	case uint16:
+		return EncodeUint(uint64(v)), nil
+	case uint32:
+		return EncodeUint(uint64(v)), nil
+	case uint64:
+		return EncodeUint(v), nil
+	case int:
+		return encodeInt(int64(v))
+	case int8:
+		return encodeInt(int64(v))
+	case int16:
+		return encodeInt(int64(v))
+	case int32:
+		return encodeInt(int64(v))
+	case int64:
+		return encodeInt(v)
+	case *big.Int:
+		return EncodeBigInt(v)
+	case []interface{}:
+		items := make([][]byte, 0, len(v))
+		for _, item := range v {
+			encoded, err := Encode(item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, encoded)
+		}
+		return EncodeList(items...), nil
+	default:
+		return nil, fmt.Errorf("%w %T", ErrUnsupportedType, v)
+	}
+}
+
+func encodeValue(v Value) []byte {
+	if v.Kind == String {
+		return EncodeBytes(v.Bytes)
+	}
+	items := make([][]byte, 0, len(v.List))
+	for _, item := range v.List {
+		items = append(items, encodeValue(item))
+	}
+	return EncodeList(items...)
+}
+
+// EncodeBytes encodes the byte string
+func EncodeBytes(b []byte) []byte {
+	if len(b) == 1 && b[0] < offsetShortString {
+		return []byte{b[0]}
+	}
+	return append(header(offsetShortString, offsetLongString, len(b)), b...)
+}
+
+// EncodeList encodes the list of the already encoded items
+func EncodeList(items ...[]byte) []byte {
+	size := 0
+	for _, item := range items {
+		size += len(item)
+	}
+	encoded := header(offsetShortList, offsetLongList, size)
+	for _, item := range items {
+		encoded = append(encoded, item...)
+	}
+	return encoded
+}
+
+// EncodeUint encodes the integer as the big-endian byte string without leading zeros, 0 is the empty string
+func EncodeUint(u uint64) []byte {
+	return EncodeBytes(uintBytes(u))
+}
+
+// EncodeBigInt encodes the non-negative integer like EncodeUint
+func EncodeBigInt(i *big.Int) ([]byte, error) {
+	if i.Sign() < 0 {
+		return nil, ErrNegativeInteger
+	}
+	return EncodeBytes(i.Bytes()), nil
+}
+
+func encodeInt(i int64) ([]byte, error) {
+	if i < 0 {
+		return nil, ErrNegativeInteger
+	}
+	return EncodeUint(uint64(i)), nil
+}
+
+// header returns the prefix of the payload of the given size
+func header(shortOffset, longOffset byte, size int) []byte {
+	if size <= maxShortSize {
+		return []byte{shortOffset + byte(size)}
+	}
+	sizeBytes := uintBytes(uint64(size))
+	return append([]byte{longOffset + byte(len(sizeBytes))}, sizeBytes...)
+}
+
+// uintBytes returns the big-endian bytes of the integer without leading zeros
+func uintBytes(u uint64) []byte {
+	var b []byte
+	for ; u > 0; u >>= 8 {
+		b = append([]byte{byte(u)}, b...)
+	}
+	return b
+}
+
+// Decode decodes the single item which the input consists of
+func Decode(b []byte) (Value, error) {
+	v, rest, err := Split(b)
+	if err != nil {
+		return v, err
+	}
+	if len(rest) > 0 {
+		return v, ErrTrailingBytes
+	}
+	return v, nil
+}
+
+// Split decodes the first item of the input and returns the rest of the input
+func Split(b []byte) (Value, []byte, error) {
+	kind, headerSize, size, err := readHeader(b)
+	if err != nil {
+		return Value{}, nil, err
+	}
+	end := headerSize + size
+	if uint64(len(b)) < uint64(end) {
+		return Value{}, nil, ErrUnexpectedEnd
+	}
+	payload := b[headerSize:end]
+
+	if kind == String {
+		return Value{Kind: String, Bytes: payload}, b[end:], nil
+	}
+	list := Value{Kind: List, List: []Value{}}
+	for len(payload) > 0 {
+		var item Value
+		item, payload, err = Split(payload)
+		if err != nil {
+			return Value{}, nil, err
+		}
+		list.List = append(list.List, item)
+	}
+	return list, b[end:], nil
+}
+
+// readHeader returns the kind of the item, the size of its header and the size of its payload
+// It rejects the headers which are not the shortest possible ones
+func readHeader(b []byte) (kind Kind, headerSize int, size int, err error) {
+	if len(b) == 0 {
+		return 0, 0, 0, ErrUnexpectedEnd
+	}
+	prefix := b[0]
+	switch {
+	case prefix < offsetShortString:
+		// The byte is its own encoding
+		return String, 0, 1, nil
+	case prefix <= offsetLongString:
+		size = int(prefix - offsetShortString)
+		if size == 1 && len(b) > 1 && b[1] < offsetShortString {
+			return 0, 0, 0, ErrNonCanonicalSize
+		}
+		return String, 1, size, nil
+	case prefix < offsetShortList:
+		size, err = readLongSize(b, int(prefix-offsetLongString))
+		return String, 1 + int(prefix-offsetLongString), size, err
+	case prefix <= offsetLongList:
+		return List, 1, int(prefix - offsetShortList), nil
+	default:
+		size, err = readLongSize(b, int(prefix-offsetLongList))
+		return List, 1 + int(prefix-offsetLongList), size, err
+	}
+}
+
+// readLongSize reads the size of the long string or list which takes sizeBytes after the prefix
+func readLongSize(b []byte, sizeBytes int) (int, error) {
+	if len(b) < 1+sizeBytes {
+		return 0, ErrUnexpectedEnd
+	}
+	if b[1] == 0 {
+		return 0, ErrNonCanonicalSize
+	}
+	if sizeBytes > 4 {
+		// The items of 4 GiB and larger are not supported
+		return 0, fmt.Errorf("%w: size of %d bytes", ErrItemTooLarge, sizeBytes)
+	}
+	size := 0
+	for _, c := range b[1 : 1+sizeBytes] {
+		size = size<<8 | int(c)
+	}
+	if size <= maxShortSize {
+		return 0, ErrNonCanonicalSize
+	}
+	return size, nil
+}
@@ -0,0 +1,153 @@
+package rlp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// vector is a test of the ethereum/tests RLP format, the integers which don't fit uint64 are strings starting with #
+type vector struct {
+	In  interface{} `json:"in"`
+	Out string      `json:"out"`
+}
+
+func readVectors(t *testing.T, path string) map[string]vector {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var vectors map[string]vector
+	require.NoError(t, decoder.Decode(&vectors))
+	require.NotEmpty(t, vectors)
+	return vectors
+}
+
+// vectorInput converts the input of the vector into the value supported by Encode
+func vectorInput(t *testing.T, in interface{}) interface{} {
+	switch in := in.(type) {
+	case json.Number:
+		u, ok := new(big.Int).SetString(in.String(), 10)
+		require.True(t, ok)
+		return u.Uint64()
+	case string:
+		if strings.HasPrefix(in, "#") {
+			i, ok := new(big.Int).SetString(in[1:], 10)
+			require.True(t, ok)
+			return i
+		}
+		return in
+	case []interface{}:
+		items := make([]interface{}, 0, len(in))
+		for _, item := range in {
+			items = append(items, vectorInput(t, item))
+		}
+		return items
+	default:
+		require.Failf(t, "unsupported input", "%T", in)
+		return nil
+	}
+}
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	require.NoError(t, err)
+	return b
+}
+
+func TestEncode_Vectors(t *testing.T) {
+	for name, v := range readVectors(t, "testdata/rlptest.json") {
+		v := v
+		t.Run(name, func(t *testing.T) {
+			expected := decodeHex(t, v.Out)
+			encoded, err := Encode(vectorInput(t, v.In))
+			require.NoError(t, err)
+			require.Equal(t, expected, encoded)
+
+			decoded, err := Decode(expected)
+			require.NoError(t, err)
+			require.Equal(t, expected, encodeValue(decoded))
+		})
+	}
+}
+
+func TestDecode_InvalidVectors(t *testing.T) {
+	for name, v := range readVectors(t, "testdata/invalidRLPTest.json") {
+		v := v
+		t.Run(name, func(t *testing.T) {
+			_, err := Decode(decodeHex(t, v.Out))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	v, err := Decode(decodeHex(t, "0xc6827a77c10401"))
+	require.NoError(t, err)
+	require.Equal(t, Value{Kind: List, List: []Value{
+		{Kind: String, Bytes: []byte("zw")},
+		{Kind: List, List: []Value{{Kind: String, Bytes: []byte{4}}}},
+		{Kind: String, Bytes: []byte{1}},
+	}}, v)
+
+	_, err = Decode(decodeHex(t, "0x8301020304"))
+	require.ErrorIs(t, err, ErrTrailingBytes)
+	_, err = Decode(decodeHex(t, "0x8100"))
+	require.ErrorIs(t, err, ErrNonCanonicalSize)
+	_, err = Decode(decodeHex(t, "0x83010203")[:2])
+	require.ErrorIs(t, err, ErrUnexpectedEnd)
+}
+
+func TestSplit(t *testing.T) {
+	v, rest, err := Split(decodeHex(t, "0x83646f67c0"))
+	require.NoError(t, err)
+	require.Equal(t, Value{Kind: String, Bytes: []byte("dog")}, v)
+	require.Equal(t, []byte{0xC0}, rest)
+}
+
+func TestValue_Integers(t *testing.T) {
+	tests := map[string]struct {
+		value         Value
+		expected      uint64
+		expectedError error
+	}{
+		"zero":          {value: Value{Kind: String}, expected: 0},
+		"1000":          {value: Value{Kind: String, Bytes: []byte{0x03, 0xE8}}, expected: 1000},
+		"max uint64":    {value: Value{Kind: String, Bytes: bytes.Repeat([]byte{0xFF}, 8)}, expected: 1<<64 - 1},
+		"overflow":      {value: Value{Kind: String, Bytes: bytes.Repeat([]byte{0xFF}, 9)}, expectedError: ErrUint64Overflow},
+		"leading zeros": {value: Value{Kind: String, Bytes: []byte{0, 1}}, expectedError: ErrNonCanonicalInteger},
+		"list":          {value: Value{Kind: List}, expectedError: ErrExpectedString},
+	}
+
+	for tName, tc := range tests {
+		tc := tc
+		t.Run(tName, func(t *testing.T) {
+			u, err := tc.value.Uint64()
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, u)
+
+			i, err := tc.value.BigInt()
+			require.NoError(t, err)
+			require.Equal(t, new(big.Int).SetUint64(tc.expected), i)
+		})
+	}
+}
+
+func TestEncode_Errors(t *testing.T) {
+	_, err := Encode(-1)
+	require.ErrorIs(t, err, ErrNegativeInteger)
+	_, err = Encode(big.NewInt(-1))
+	require.ErrorIs(t, err, ErrNegativeInteger)
+	_, err = Encode([]interface{}{"a", 1.5})
+	require.ErrorIs(t, err, ErrUnsupportedType)
+}
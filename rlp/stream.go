@@ -0,0 +1,57 @@
+package rlp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// Stream reads the consecutive items from a reader
+type Stream struct {
+	reader  *bufio.Reader
+	maxSize int
+}
+
+// NewStream creates a stream which rejects the items larger than maxSize bytes, 0 means no limit
+func NewStream(reader io.Reader, maxSize int) *Stream {
+	return &Stream{reader: bufio.NewReader(reader), maxSize: maxSize}
+}
+
+// Next returns the encoding of the next item, which can be decoded with Decode
+// It returns io.EOF when there are no more items and io.ErrUnexpectedEOF when the input ends in the middle of an item
+func (s *Stream) Next() ([]byte, error) {
+	prefix, err := s.reader.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	headerBytes := 1
+	if p := prefix[0]; p > offsetLongString && p < offsetShortList {
+		headerBytes += int(p - offsetLongString)
+	} else if p > offsetLongList {
+		headerBytes += int(p - offsetLongList)
+	}
+	b, err := s.reader.Peek(headerBytes)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	_, headerSize, size, err := readHeader(b)
+	if err != nil {
+		return nil, err
+	}
+	if s.maxSize > 0 && headerSize+size > s.maxSize {
+		return nil, fmt.Errorf("%w: %d bytes", ErrItemTooLarge, headerSize+size)
+	}
+
+	item := make([]byte, headerSize+size)
+	if _, err = io.ReadFull(s.reader, item); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return item, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
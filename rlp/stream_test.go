@@ -0,0 +1,60 @@
+package rlp
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStream_Next(t *testing.T) {
+	long := EncodeBytes(bytes.Repeat([]byte{'a'}, 300))
+	input := bytes.Join([][]byte{{0x01}, EncodeBytes([]byte("dog")), long, EncodeList(EncodeUint(1000))}, nil)
+
+	stream := NewStream(bytes.NewReader(input), 0)
+	var items [][]byte
+	for {
+		item, err := stream.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		items = append(items, item)
+	}
+	require.Equal(t, [][]byte{{0x01}, {0x83, 'd', 'o', 'g'}, long, {0xC3, 0x82, 0x03, 0xE8}}, items)
+}
+
+func TestStream_Errors(t *testing.T) {
+	tests := map[string]struct {
+		input         []byte
+		maxSize       int
+		expectedError error
+	}{
+		"truncated payload": {
+			input:         []byte{0x83, 'd', 'o'},
+			expectedError: io.ErrUnexpectedEOF,
+		},
+		"truncated size": {
+			input:         []byte{0xB9, 0x01},
+			expectedError: io.ErrUnexpectedEOF,
+		},
+		"non-canonical size": {
+			input:         []byte{0xB8, 0x01, 'a'},
+			expectedError: ErrNonCanonicalSize,
+		},
+		"too large": {
+			input:         EncodeBytes(bytes.Repeat([]byte{'a'}, 100)),
+			maxSize:       64,
+			expectedError: ErrItemTooLarge,
+		},
+	}
+
+	for tName, tc := range tests {
+		tc := tc
+		t.Run(tName, func(t *testing.T) {
+			_, err := NewStream(bytes.NewReader(tc.input), tc.maxSize).Next()
+			require.ErrorIs(t, err, tc.expectedError)
+		})
+	}
+}